@@ -59,7 +59,7 @@ func (client *Client) GetAccount(options *RequestOptions) (Account, error) {
 		return Account{}, fmt.Errorf("making request for account: %w", err)
 	}
 
-	resp, err := requestJSON[Account](options, client, req, http.StatusOK)
+	resp, err := requestJSON[Account](options, client, &Call{Operation: "GetAccount"}, req, http.StatusOK)
 	if err != nil {
 		return Account{}, fmt.Errorf("requesting JSON data: %w", err)
 	}
@@ -0,0 +1,162 @@
+package synthient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// ErrInvalidAddr is matched by every *AddrError.
+var ErrInvalidAddr = errors.New("invalid ip address")
+
+// AddrError reports an IP address rejected before any request was sent, either
+// because it does not parse or because it is in a range the API has no data for. It
+// matches ErrInvalidAddr and ErrBadRequest with errors.Is.
+type AddrError struct {
+	// Input is the address as given.
+	Input string
+	// Addr is the parsed address, when parsing succeeded.
+	Addr netip.Addr
+	// Reason describes the problem, e.g. "private address".
+	Reason string
+}
+
+func (e *AddrError) Error() string {
+	return fmt.Sprintf("%s %q: %s", ErrInvalidAddr, e.Input, e.Reason)
+}
+
+func (e *AddrError) Unwrap() []error { return []error{ErrInvalidAddr, ErrBadRequest} }
+
+// reservedPrefixes are special-purpose ranges (RFC 6890 and successors) that are
+// globally unicast by netip's definition but never routed on the public internet.
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("100::/64"),
+	netip.MustParsePrefix("2001:db8::/32"),
+	netip.MustParsePrefix("3fff::/20"),
+}
+
+// ParseAddr validates and canonicalizes an IP address for lookup. Surrounding space is
+// trimmed and IPv4-mapped IPv6 addresses are unmapped to IPv4. Inputs that do not
+// parse (including IPv4 with leading zeros), zoned IPv6 addresses, and addresses
+// rejected by ValidateAddr return an *AddrError.
+//
+// GetIP, GetIPs and LookupIPsBulk apply it to their inputs, so rejected addresses fail
+// without a request or a spent credit.
+func ParseAddr(s string) (netip.Addr, error) {
+	addr, err := netip.ParseAddr(strings.TrimSpace(s))
+	if err != nil {
+		return netip.Addr{}, &AddrError{Input: s, Reason: "not an ip address"}
+	}
+	if addr.Zone() != "" {
+		return netip.Addr{}, &AddrError{Input: s, Addr: addr, Reason: "zoned address"}
+	}
+	addr = addr.Unmap()
+	err = ValidateAddr(addr)
+	if err != nil {
+		var addrErr *AddrError
+		if errors.As(err, &addrErr) {
+			addrErr.Input = s
+		}
+		return netip.Addr{}, err
+	}
+	return addr, nil
+}
+
+// ValidateAddr reports whether addr can be looked up, returning an *AddrError for
+// invalid, zoned, unspecified, loopback, link-local, multicast, private (RFC 1918 and
+// fc00::/7) and other reserved addresses such as shared address space and the
+// documentation ranges. IPv4-mapped addresses are judged by their IPv4 address.
+func ValidateAddr(addr netip.Addr) error {
+	reject := func(reason string) error {
+		return &AddrError{Input: addr.String(), Addr: addr, Reason: reason}
+	}
+	if !addr.IsValid() {
+		return &AddrError{Input: addr.String(), Reason: "not an ip address"}
+	}
+	if addr.Zone() != "" {
+		return reject("zoned address")
+	}
+	addr = addr.Unmap()
+	switch {
+	case addr.IsUnspecified():
+		return reject("unspecified address")
+	case addr.IsLoopback():
+		return reject("loopback address")
+	case addr.IsLinkLocalUnicast(), addr.IsLinkLocalMulticast():
+		return reject("link-local address")
+	case addr.IsMulticast():
+		return reject("multicast address")
+	case addr.IsPrivate():
+		return reject("private address")
+	case !addr.IsGlobalUnicast():
+		return reject("not a global unicast address")
+	}
+	for _, prefix := range reservedPrefixes {
+		if prefix.Contains(addr) {
+			return reject("reserved address")
+		}
+	}
+	return nil
+}
+
+// canonicalIPs validates ips, returning their canonical string forms.
+func canonicalIPs(ips []string) ([]string, error) {
+	canonical := make([]string, len(ips))
+	for i, ip := range ips {
+		addr, err := ParseAddr(ip)
+		if err != nil {
+			return nil, err
+		}
+		canonical[i] = addr.String()
+	}
+	return canonical, nil
+}
+
+// UnmarshalJSON decodes an IP lookup result and fills in Addr.
+func (ip *IP) UnmarshalJSON(data []byte) error {
+	type plain IP
+	err := json.Unmarshal(data, (*plain)(ip))
+	if err != nil {
+		return err
+	}
+	ip.Addr, _ = netip.ParseAddr(ip.IP)
+	return nil
+}
+
+// LookupAddr is GetIP for a parsed address. addr is checked with ValidateAddr first.
+//
+// Example:
+//
+//	addr := netip.MustParseAddr("8.8.8.8")
+//	info, err := client.LookupAddr(addr, nil)
+func (client *Client) LookupAddr(addr netip.Addr, options *RequestOptions) (IP, error) {
+	err := ValidateAddr(addr)
+	if err != nil {
+		return IP{}, err
+	}
+	return client.GetIP(addr.Unmap().String(), options)
+}
+
+// LookupAddrs is GetIPs for parsed addresses. Every address is checked with
+// ValidateAddr before the request is sent.
+func (client *Client) LookupAddrs(addrs []netip.Addr, options *RequestOptions) ([]IP, error) {
+	ips := make([]string, len(addrs))
+	for i, addr := range addrs {
+		err := ValidateAddr(addr)
+		if err != nil {
+			return []IP{}, err
+		}
+		ips[i] = addr.Unmap().String()
+	}
+	return client.GetIPs(ips, options)
+}
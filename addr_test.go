@@ -0,0 +1,68 @@
+package synthient
+
+import (
+	"errors"
+	"net/http"
+	"net/netip"
+	"sync/atomic"
+	"testing"
+)
+
+func TestParseAddr(t *testing.T) {
+	valid := map[string]string{
+		"8.8.8.8":            "8.8.8.8",
+		" 1.1.1.1\n":         "1.1.1.1",
+		"::ffff:8.8.4.4":     "8.8.4.4",
+		"2606:4700:4700::11": "2606:4700:4700::11",
+	}
+	for input, want := range valid {
+		addr, err := ParseAddr(input)
+		if err != nil || addr.String() != want {
+			t.Errorf("ParseAddr(%q) = %s, %v, want %s", input, addr, err, want)
+		}
+	}
+
+	for _, input := range []string{
+		"", "example.com", "08.8.8.8", "fe80::1%eth0", "::ffff:10.0.0.1",
+		"10.1.2.3", "192.168.0.1", "172.16.5.4", "fd00::1", "127.0.0.1", "::1",
+		"0.0.0.0", "169.254.1.1", "224.0.0.1", "255.255.255.255", "100.64.0.1",
+		"203.0.113.7", "2001:db8::1",
+	} {
+		_, err := ParseAddr(input)
+		var addrErr *AddrError
+		if !errors.As(err, &addrErr) || addrErr.Input != input {
+			t.Errorf("ParseAddr(%q) err = %v, want *AddrError", input, err)
+		}
+		if !errors.Is(err, ErrInvalidAddr) || !errors.Is(err, ErrBadRequest) {
+			t.Errorf("ParseAddr(%q) err = %v does not match the sentinels", input, err)
+		}
+	}
+}
+
+func TestLookupAddrValidatesBeforeRequest(t *testing.T) {
+	var calls atomic.Int32
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		_, _ = w.Write([]byte(`{"ip":"8.8.8.8"}`))
+	}))
+
+	_, err := client.LookupAddrs([]netip.Addr{netip.MustParseAddr("8.8.8.8"), netip.MustParseAddr("10.0.0.1")}, nil)
+	if !errors.Is(err, ErrInvalidAddr) {
+		t.Fatalf("err = %v, want ErrInvalidAddr", err)
+	}
+	_, err = client.GetIP("::1", nil)
+	if !errors.Is(err, ErrInvalidAddr) {
+		t.Fatalf("err = %v, want ErrInvalidAddr", err)
+	}
+	if calls.Load() != 0 {
+		t.Fatalf("server called %d times for rejected input", calls.Load())
+	}
+
+	ip, err := client.LookupAddr(netip.MustParseAddr("::ffff:8.8.8.8"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ip.Addr != netip.MustParseAddr("8.8.8.8") {
+		t.Errorf("Addr = %s, want 8.8.8.8", ip.Addr)
+	}
+}
@@ -6,8 +6,11 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
+	"net/url"
 	"strings"
+	"time"
 )
 
 // RequestOptions configures optional per-request behavior for client calls.
@@ -16,85 +19,195 @@ import (
 // changing the Client itself. When Context is non-nil, it is used for request
 // cancellation, deadlines, and timeouts. If Context is nil, the request uses
 // context.Background() (or the client/request default).
+//
+// When Response is non-nil it is filled with the status, headers, request ID, credit
+// and rate-limit information of the call's HTTP response; see ResponseMeta. Use a
+// separate ResponseMeta for each concurrent call.
 type RequestOptions struct {
-	Context context.Context
+	Context  context.Context
+	Response *ResponseMeta
+}
+
+// context returns options.Context, or context.Background() when unset.
+func (options *RequestOptions) context() context.Context {
+	if options == nil || options.Context == nil {
+		return context.Background()
+	}
+	return options.Context
 }
 
 // IMPORTANT: make sure to close the returned reader
 func request(
 	options *RequestOptions,
 	client *Client,
+	call *Call,
 	request *http.Request,
 	expectedStatusCode int,
 ) (io.ReadCloser, error) {
-	if options == nil {
-		options = &RequestOptions{Context: context.Background()}
-	}
+	ctx := options.context()
 
-	if strings.TrimSpace(client.Token) == "" {
+	if client.Tokens == nil && strings.TrimSpace(client.Token) == "" {
 		return nil, ErrNoToken
 	}
-	request.Header.Add("X-Api-Key", client.Token)
-	request = request.WithContext(options.Context)
 
-	response, err := client.HttpClient.Do(request)
+	call.Start = time.Now()
+	invoke := client.chain(call, func(req *http.Request) (*http.Response, error) {
+		return client.roundTrip(call, req, expectedStatusCode)
+	})
+	response, err := invoke(request.WithContext(ctx))
+	if response != nil && options != nil && options.Response != nil {
+		*options.Response = newResponseMeta(response, call.attempts, time.Since(call.Start))
+	}
 	if err != nil {
-		return nil, fmt.Errorf("performing request to %s: %w", request.URL.String(), err)
+		client.log(ctx, slog.LevelWarn, "synthient request failed", call,
+			slog.Duration("duration", time.Since(call.Start)),
+			slog.String("error", err.Error()),
+		)
+		return nil, err
 	}
+	client.log(ctx, slog.LevelDebug, "synthient request completed", call,
+		slog.Int("status", response.StatusCode),
+		slog.Duration("duration", time.Since(call.Start)),
+	)
+	return response.Body, nil
+}
 
-	fail := func(err error) (io.ReadCloser, error) {
-		closeErr := response.Body.Close()
-		if closeErr != nil {
-			return nil, fmt.Errorf("closing file: %w", errors.Join(err, closeErr))
-		}
-		return nil, err
+// roundTrip performs request, retrying it according to client.Retry.
+func (client *Client) roundTrip(call *Call, request *http.Request, expectedStatusCode int) (*http.Response, error) {
+	ctx := request.Context()
+	attempts := 1
+	if isIdempotent(request) {
+		attempts = client.Retry.attempts()
 	}
 
-	switch response.StatusCode {
-	case http.StatusBadRequest:
-		primaryError := ErrBadRequest
-		body, err := io.ReadAll(response.Body)
+	rejected := false
+	for attempt := 1; ; attempt++ {
+		call.attempts = attempt
+		token, err := client.token(ctx)
 		if err != nil {
-			return fail(primaryError)
+			return nil, err
+		}
+		response, err := attemptRequest(client, call, request, token, expectedStatusCode)
+		if err == nil {
+			return response, nil
+		}
+		replayable := request.Body == nil || request.GetBody != nil
+		if !rejected && replayable && client.rejectToken(ctx, token, err) {
+			rejected = true
+			attempts++
+			client.log(ctx, slog.LevelWarn, "synthient api key rejected, retrying with next key", call)
+			continue
+		}
+		if attempt >= attempts || !client.Retry.retryable(err) || ctx.Err() != nil {
+			return response, err
+		}
+		if !replayable {
+			return response, err
 		}
-		var e struct {
-			Error string `json:"error"`
+		var header http.Header
+		if response != nil {
+			header = response.Header
 		}
-		err = json.Unmarshal(body, &e)
+		wait := client.Retry.backoff(attempt, header)
+		client.log(ctx, slog.LevelWarn, "retrying synthient request", call,
+			slog.Int("attempt", attempt),
+			slog.Duration("wait", wait),
+			slog.String("error", err.Error()),
+		)
+		sleepErr := sleepContext(ctx, wait)
+		if sleepErr != nil {
+			return response, fmt.Errorf("waiting to retry request: %w", errors.Join(err, sleepErr))
+		}
+	}
+}
+
+// attemptRequest performs a single round trip for request. When the status code is
+// unexpected the response is returned, with its body closed, alongside the *APIError
+// so callers can inspect headers such as Retry-After.
+func attemptRequest(
+	client *Client,
+	call *Call,
+	request *http.Request,
+	token string,
+	expectedStatusCode int,
+) (*http.Response, error) {
+	request = request.Clone(request.Context())
+	if request.GetBody != nil {
+		body, err := request.GetBody()
 		if err != nil {
-			return fail(primaryError)
+			return nil, fmt.Errorf("rewinding request body: %w", err)
 		}
-		return fail(fmt.Errorf("%w: %s", primaryError, e.Error))
-	case http.StatusUnauthorized:
-		return fail(ErrUnauthorized)
-	case http.StatusPaymentRequired:
-		return fail(ErrPaymentRequired)
-	case http.StatusInternalServerError:
-		return fail(ErrInternalServerError)
+		request.Body = body
 	}
-	if response.StatusCode != expectedStatusCode {
-		err = fmt.Errorf(
-			`status of %d "%s" (%d "%s" expected) making request: %w`,
-			response.StatusCode,
-			http.StatusText(response.StatusCode),
-			expectedStatusCode,
-			http.StatusText(expectedStatusCode),
-			ErrUnexpectedStatusCode,
+	request.Header.Set("X-Api-Key", token)
+	if client.UserAgent != "" {
+		request.Header.Set("User-Agent", client.UserAgent)
+	}
+
+	client.log(request.Context(), slog.LevelDebug, "sending synthient request", call,
+		slog.String("method", request.Method),
+		slog.String("url", client.logURL(call, request.URL)),
+		slog.Any("headers", redactedHeader(request.Header)),
+	)
+
+	httpClient := client.HttpClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("performing request to %s: %w", client.logURL(call, request.URL), client.redactURLError(call, err))
+	}
+	if response.Request != nil && response.Request.URL.String() != request.URL.String() {
+		client.log(request.Context(), slog.LevelDebug, "followed synthient redirect", call,
+			slog.String("from", client.logURL(call, request.URL)),
+			slog.String("to", client.logURL(call, response.Request.URL)),
 		)
-		return fail(err)
 	}
 
-	return response.Body, nil
+	if response.StatusCode != expectedStatusCode {
+		requestURL := request.URL
+		if response.Request != nil {
+			requestURL = response.Request.URL
+		}
+		err = newAPIError(response, expectedStatusCode, client.logURL(call, requestURL))
+		closeErr := response.Body.Close()
+		if closeErr != nil {
+			return response, fmt.Errorf("closing file: %w", errors.Join(err, closeErr))
+		}
+		return response, err
+	}
+
+	return response, nil
+}
+
+// redactURLError replaces the URL of a *url.Error from http.Client.Do, which may be a
+// redirect target with presigned credentials or hold the looked-up IP, with its logURL
+// form.
+func (client *Client) redactURLError(call *Call, err error) error {
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		return err
+	}
+	redacted := *urlErr
+	u, parseErr := url.Parse(urlErr.URL)
+	if parseErr != nil {
+		redacted.URL = ""
+	} else {
+		redacted.URL = client.logURL(call, u)
+	}
+	return &redacted
 }
 
 func requestJSON[T any](
 	options *RequestOptions,
 	client *Client,
+	call *Call,
 	req *http.Request,
 	expectedStatusCode int,
 ) (T, error) {
 	var zero T // to be used as "nil"
-	body, err := request(options, client, req, expectedStatusCode)
+	body, err := request(options, client, call, req, expectedStatusCode)
 	if err != nil {
 		return zero, fmt.Errorf("making request: %w", err)
 	}
@@ -103,6 +216,9 @@ func requestJSON[T any](
 	var data T
 	err = json.NewDecoder(body).Decode(&data)
 	if err != nil {
+		client.log(options.context(), slog.LevelError, "decoding synthient response failed", call,
+			slog.String("error", err.Error()),
+		)
 		return zero, fmt.Errorf("parsing json: %w", err)
 	}
 
@@ -0,0 +1,210 @@
+package synthient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+	"sync"
+)
+
+// DefaultBulkChunkSize is the number of IPs LookupIPsBulk sends per GetIPs request
+// unless BulkOptions.ChunkSize says otherwise.
+const DefaultBulkChunkSize = 100
+
+// DefaultBulkConcurrency is the number of GetIPs requests LookupIPsBulk keeps in flight
+// unless BulkOptions.Concurrency says otherwise.
+const DefaultBulkConcurrency = 4
+
+// BulkOptions configures LookupIPsBulk.
+type BulkOptions struct {
+	// ChunkSize is the number of IPs sent per request. Defaults to DefaultBulkChunkSize.
+	ChunkSize int
+	// Concurrency is the maximum number of requests in flight. Defaults to
+	// DefaultBulkConcurrency.
+	Concurrency int
+}
+
+// BulkLookupError is the error LookupIPsBulk yields for an address it could not look
+// up. It unwraps to the cause, so errors.Is works with the status sentinels,
+// ErrQuotaExhausted and context errors.
+type BulkLookupError struct {
+	IP  string
+	Err error
+}
+
+func (e *BulkLookupError) Error() string {
+	return fmt.Sprintf("looking up %s: %s", e.IP, e.Err)
+}
+
+func (e *BulkLookupError) Unwrap() error { return e.Err }
+
+// errNoResult is the BulkLookupError cause for an address missing from a GetIPs
+// response.
+var errNoResult = errors.New("no result for the address in the bulk response")
+
+// resultKey returns the canonical address of a lookup result, matching the form
+// ParseAddr gives the requested address, or ip.IP when it does not parse.
+func resultKey(ip IP) string {
+	if ip.Addr.IsValid() {
+		return ip.Addr.Unmap().String()
+	}
+	addr, err := ParseAddr(ip.IP)
+	if err != nil {
+		return ip.IP
+	}
+	return addr.String()
+}
+
+// resultsByAddr indexes GetIPs results by resultKey.
+func resultsByAddr(records []IP) map[string]IP {
+	byAddr := make(map[string]IP, len(records))
+	for _, record := range records {
+		byAddr[resultKey(record)] = record
+	}
+	return byAddr
+}
+
+// LookupIPsBulk looks up any number of IP addresses. The input is deduplicated, split
+// into chunks of bulk.ChunkSize and sent with GetIPs using up to bulk.Concurrency
+// concurrent requests, so client.Retry and client.Limiter apply to every chunk.
+//
+// The returned iterator yields each unique address exactly once, in completion order
+// rather than input order. Addresses are canonicalized with ParseAddr, so different
+// spellings of one address count as duplicates. Failures are per address: an address
+// rejected by ParseAddr, or one that belongs to a chunk whose request failed, is
+// yielded as an IP with only the IP field set together with a *BulkLookupError, as is
+// one the response of its chunk has no result for. Results are matched to addresses
+// by IP, not by position. When the context in options is cancelled, outstanding
+// addresses are yielded with the context error. Breaking out of the loop cancels the
+// requests still in flight.
+//
+// options.Response is not filled, since a bulk lookup spans many responses.
+//
+// Example:
+//
+//	for ip, err := range client.LookupIPsBulk(addresses, nil, nil) {
+//		if err != nil {
+//			log.Printf("%s: %v", ip.IP, err)
+//			continue
+//		}
+//		fmt.Printf("%s: risk=%d\n", ip.IP, ip.Intelligence.RiskScore)
+//	}
+func (client *Client) LookupIPsBulk(
+	ips []string,
+	bulk *BulkOptions,
+	options *RequestOptions,
+) iter.Seq2[IP, error] {
+	chunkSize, concurrency := DefaultBulkChunkSize, DefaultBulkConcurrency
+	if bulk != nil && bulk.ChunkSize > 0 {
+		chunkSize = bulk.ChunkSize
+	}
+	if bulk != nil && bulk.Concurrency > 0 {
+		concurrency = bulk.Concurrency
+	}
+
+	return func(yield func(IP, error) bool) {
+		seen := make(map[string]struct{}, len(ips))
+		var valid []string
+		for _, ip := range ips {
+			addr, err := ParseAddr(ip)
+			key := ip
+			if err == nil {
+				key = addr.String()
+			}
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			if err != nil {
+				if !yield(IP{IP: ip}, &BulkLookupError{IP: ip, Err: err}) {
+					return
+				}
+				continue
+			}
+			valid = append(valid, key)
+		}
+		var chunks [][]string
+		for chunk := range slices.Chunk(valid, chunkSize) {
+			chunks = append(chunks, chunk)
+		}
+		if len(chunks) == 0 {
+			return
+		}
+
+		ctx, cancel := context.WithCancel(options.context())
+		type result struct {
+			index   int
+			records map[string]IP
+			err     error
+		}
+		jobs := make(chan int)
+		results := make(chan result)
+		defer func() {
+			cancel()
+			for range results {
+			}
+		}()
+
+		go func() {
+			defer close(jobs)
+			for i := range chunks {
+				select {
+				case jobs <- i:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+		var wg sync.WaitGroup
+		for range min(concurrency, len(chunks)) {
+			wg.Go(func() {
+				for i := range jobs {
+					records, err := client.GetIPs(chunks[i], &RequestOptions{Context: ctx})
+					select {
+					case results <- result{index: i, records: resultsByAddr(records), err: err}:
+					case <-ctx.Done():
+						return
+					}
+				}
+			})
+		}
+		go func() {
+			wg.Wait()
+			close(results)
+		}()
+
+		done := make([]bool, len(chunks))
+		for res := range results {
+			done[res.index] = true
+			for _, ip := range chunks[res.index] {
+				record, found := res.records[ip]
+				var ok bool
+				switch {
+				case res.err != nil:
+					ok = yield(IP{IP: ip}, &BulkLookupError{IP: ip, Err: res.err})
+				case !found:
+					ok = yield(IP{IP: ip}, &BulkLookupError{IP: ip, Err: errNoResult})
+				default:
+					ok = yield(record, nil)
+				}
+				if !ok {
+					return
+				}
+			}
+		}
+
+		// only reached early when the caller's context was cancelled
+		for i, chunk := range chunks {
+			if done[i] {
+				continue
+			}
+			for _, ip := range chunk {
+				if !yield(IP{IP: ip}, &BulkLookupError{IP: ip, Err: ctx.Err()}) {
+					return
+				}
+			}
+		}
+	}
+}
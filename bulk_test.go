@@ -0,0 +1,136 @@
+package synthient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync/atomic"
+	"testing"
+)
+
+func bulkHandler(t *testing.T, inFlight, peak *atomic.Int32, fail string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		var body struct {
+			IPs []string `json:"ips"`
+		}
+		err := json.NewDecoder(r.Body).Decode(&body)
+		if err != nil {
+			t.Error(err)
+		}
+		if slices.Contains(body.IPs, fail) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		results := make([]IP, len(body.IPs))
+		for i, ip := range body.IPs {
+			results[i].IP = ip
+		}
+		_ = json.NewEncoder(w).Encode(map[string][]IP{"results": results})
+	})
+}
+
+func TestLookupIPsBulk(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	client := newTestClient(t, bulkHandler(t, &inFlight, &peak, "11.0.0.7"))
+
+	var input []string
+	for i := range 50 {
+		input = append(input, fmt.Sprintf("11.0.0.%d", i), fmt.Sprintf("11.0.0.%d", i))
+	}
+	input = append(input, "not-an-ip")
+
+	got := map[string]error{}
+	for ip, err := range client.LookupIPsBulk(input, &BulkOptions{ChunkSize: 5, Concurrency: 3}, nil) {
+		if _, dup := got[ip.IP]; dup {
+			t.Fatalf("%s yielded twice", ip.IP)
+		}
+		got[ip.IP] = err
+	}
+	if len(got) != 51 {
+		t.Fatalf("yielded %d addresses, want 51", len(got))
+	}
+	if !errors.Is(got["not-an-ip"], ErrBadRequest) {
+		t.Errorf("invalid address err = %v, want ErrBadRequest", got["not-an-ip"])
+	}
+	// 11.0.0.7 shares a chunk with 11.0.0.5 through 11.0.0.9
+	for i := range 50 {
+		ip := fmt.Sprintf("11.0.0.%d", i)
+		failed := i >= 5 && i < 10
+		var bulkErr *BulkLookupError
+		if failed != errors.As(got[ip], &bulkErr) {
+			t.Errorf("%s: err = %v", ip, got[ip])
+		}
+	}
+	if !errors.Is(got["11.0.0.7"], ErrServiceUnavailable) {
+		t.Errorf("failed chunk err = %v, want ErrServiceUnavailable", got["11.0.0.7"])
+	}
+	if peak.Load() > 3 {
+		t.Errorf("peak concurrency %d, want at most 3", peak.Load())
+	}
+}
+
+func TestLookupIPsBulkCancelled(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	client := newTestClient(t, bulkHandler(t, &inFlight, &peak, ""))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	count := 0
+	for _, err := range client.LookupIPsBulk([]string{"1.1.1.1", "8.8.8.8"}, nil, &RequestOptions{Context: ctx}) {
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("err = %v, want context.Canceled", err)
+		}
+		count++
+	}
+	if count != 2 {
+		t.Errorf("yielded %d addresses, want 2", count)
+	}
+}
+
+func TestLookupIPsBulkMatchesByAddress(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			IPs []string `json:"ips"`
+		}
+		err := json.NewDecoder(r.Body).Decode(&body)
+		if err != nil {
+			t.Error(err)
+		}
+		// reversed, with a risk score derived from the address and the last one missing
+		var results []map[string]any
+		for i := len(body.IPs) - 1; i > 0; i-- {
+			results = append(results, map[string]any{
+				"ip":           body.IPs[i],
+				"intelligence": map[string]any{"risk_score": i},
+			})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"results": results})
+	}))
+
+	got := map[string]IP{}
+	var missing []string
+	for ip, err := range client.LookupIPsBulk([]string{"11.0.0.0", "11.0.0.1", "11.0.0.2"}, nil, nil) {
+		if err != nil {
+			missing = append(missing, ip.IP)
+			continue
+		}
+		got[ip.IP] = ip
+	}
+	if got["11.0.0.1"].Intelligence.RiskScore != 1 || got["11.0.0.2"].Intelligence.RiskScore != 2 {
+		t.Errorf("results were not matched by address: %+v", got)
+	}
+	if !slices.Equal(missing, []string{"11.0.0.0"}) {
+		t.Errorf("missing = %v, want [11.0.0.0]", missing)
+	}
+}
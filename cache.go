@@ -0,0 +1,328 @@
+package synthient
+
+import (
+	"container/list"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CacheOptions configures a LookupCache. Zero values select the defaults.
+type CacheOptions struct {
+	// MaxEntries bounds the number of cached IPs and domains together. The least
+	// recently used entry is evicted when the bound is reached. Defaults to 10000.
+	MaxEntries int
+	// IPTTL is how long IP results stay fresh. Defaults to one hour.
+	IPTTL time.Duration
+	// DomainTTL is how long domain results stay fresh. Defaults to one hour.
+	DomainTTL time.Duration
+	// NegativeTTL enables caching of 400 Bad Request failures from GetIP and GetDomain
+	// for the given duration, so invalid input is not sent again. Zero disables it.
+	NegativeTTL time.Duration
+	// Store, when non-nil, persists successful results beneath the in-memory entries,
+	// e.g. across process restarts (see the diskcache package). Results read back from
+	// it stay fresh until their fetch time plus IPTTL or DomainTTL. Failed lookups are
+	// never persisted.
+	Store CacheStore
+}
+
+// CacheRecord is a lookup result persisted by a CacheStore.
+type CacheRecord struct {
+	// Key identifies the lookup, e.g. "ip:8.8.8.8" or "domain:example.com".
+	Key string `json:"key"`
+	// Value is the JSON-encoded IP or Domain.
+	Value json.RawMessage `json:"value"`
+	// FetchedAt is when the result was received from the API.
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// CacheStore persists lookup results for a LookupCache. Implementations must be safe
+// for concurrent use.
+type CacheStore interface {
+	// Get returns the record stored under key, reporting false when there is none.
+	Get(key string) (CacheRecord, bool, error)
+	// Put stores record, replacing any record with the same key.
+	Put(record CacheRecord) error
+}
+
+// CacheStats reports LookupCache activity.
+type CacheStats struct {
+	Hits      int64
+	Misses    int64
+	Evictions int64
+	Entries   int
+	// StoreHits counts the hits served from CacheOptions.Store rather than memory;
+	// they are included in Hits.
+	StoreHits int64
+	// StoreErrors counts failed reads and writes of CacheOptions.Store. A failing
+	// store never fails a lookup.
+	StoreErrors int64
+}
+
+// LookupCache caches GetIP, GetIPs and GetDomain results in memory so repeated
+// lookups do not spend credits. Attach it through Client.Cache or WithCache. GetIPs
+// only sends the addresses that are not cached. Set CacheOptions.Store to keep results
+// across restarts.
+//
+// Cache hits do not go through middleware, the quota limiter or the network, and do
+// not fill RequestOptions.Response. A LookupCache is safe for concurrent use and may be
+// shared by several clients using the same API key.
+//
+// Example:
+//
+//	cache := synthient.NewLookupCache(synthient.CacheOptions{MaxEntries: 50000, IPTTL: 6 * time.Hour})
+//	client := synthient.NewClient(os.Getenv("SYNTHIENT_API_KEY"), synthient.WithCache(cache))
+//	...
+//	stats := cache.Stats()
+//	fmt.Printf("hits=%d misses=%d\n", stats.Hits, stats.Misses)
+type LookupCache struct {
+	options CacheOptions
+
+	mu          sync.Mutex
+	entries     map[string]*list.Element
+	order       *list.List // front is most recently used
+	hits        int64
+	misses      int64
+	evictions   int64
+	storeHits   int64
+	storeErrors int64
+}
+
+type cacheEntry struct {
+	key     string
+	value   any // IP, Domain, or error for negative entries
+	expires time.Time
+}
+
+// NewLookupCache returns an empty LookupCache.
+func NewLookupCache(options CacheOptions) *LookupCache {
+	if options.MaxEntries <= 0 {
+		options.MaxEntries = 10000
+	}
+	if options.IPTTL <= 0 {
+		options.IPTTL = time.Hour
+	}
+	if options.DomainTTL <= 0 {
+		options.DomainTTL = time.Hour
+	}
+	return &LookupCache{
+		options: options,
+		entries: map[string]*list.Element{},
+		order:   list.New(),
+	}
+}
+
+// WithCache sets Client.Cache.
+func WithCache(cache *LookupCache) Option {
+	return func(client *Client) {
+		client.Cache = cache
+	}
+}
+
+// Stats returns the hit, miss and eviction counters and the current entry count.
+func (cache *LookupCache) Stats() CacheStats {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	return CacheStats{
+		Hits:        cache.hits,
+		Misses:      cache.misses,
+		Evictions:   cache.evictions,
+		Entries:     cache.order.Len(),
+		StoreHits:   cache.storeHits,
+		StoreErrors: cache.storeErrors,
+	}
+}
+
+// Purge removes every in-memory entry. Counters and the Store are kept.
+func (cache *LookupCache) Purge() {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	clear(cache.entries)
+	cache.order.Init()
+}
+
+func ipCacheKey(ip string) string { return "ip:" + ip }
+
+func domainCacheKey(domain string) string { return "domain:" + strings.ToLower(domain) }
+
+func (cache *LookupCache) ttl(key string) time.Duration {
+	if strings.HasPrefix(key, "ip:") {
+		return cache.options.IPTTL
+	}
+	return cache.options.DomainTTL
+}
+
+// get returns the cached value for key, falling back to the store on a memory miss.
+// Expired entries count as misses.
+func (cache *LookupCache) get(key string) (any, bool) {
+	cache.mu.Lock()
+	element, ok := cache.entries[key]
+	if ok && time.Now().After(element.Value.(*cacheEntry).expires) {
+		cache.removeLocked(element)
+		ok = false
+	}
+	if ok {
+		defer cache.mu.Unlock()
+		cache.hits++
+		cache.order.MoveToFront(element)
+		return cloneValue(element.Value.(*cacheEntry).value), true
+	}
+	if cache.options.Store == nil {
+		defer cache.mu.Unlock()
+		cache.misses++
+		return nil, false
+	}
+	cache.mu.Unlock()
+
+	value, expires, err := cache.load(key)
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if err != nil {
+		cache.storeErrors++
+	}
+	if value == nil {
+		cache.misses++
+		return nil, false
+	}
+	cache.hits++
+	cache.storeHits++
+	cache.insertLocked(&cacheEntry{key: key, value: value, expires: expires})
+	return cloneValue(value), true
+}
+
+// load reads key from the store, returning a nil value when it is missing or stale.
+func (cache *LookupCache) load(key string) (any, time.Time, error) {
+	record, ok, err := cache.options.Store.Get(key)
+	if err != nil || !ok {
+		return nil, time.Time{}, err
+	}
+	expires := record.FetchedAt.Add(cache.ttl(key))
+	if time.Now().After(expires) {
+		return nil, time.Time{}, nil
+	}
+	var value any
+	if strings.HasPrefix(key, "ip:") {
+		var ip IP
+		err = json.Unmarshal(record.Value, &ip)
+		value = ip
+	} else {
+		var domain Domain
+		err = json.Unmarshal(record.Value, &domain)
+		value = domain
+	}
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("decoding cached %s: %w", key, err)
+	}
+	return value, expires, nil
+}
+
+// put caches value, an IP, Domain or error, for ttl. Results are also written to the
+// store.
+func (cache *LookupCache) put(key string, value any, ttl time.Duration) {
+	now := time.Now()
+	cache.mu.Lock()
+	cache.insertLocked(&cacheEntry{key: key, value: cloneValue(value), expires: now.Add(ttl)})
+	cache.mu.Unlock()
+
+	if _, failed := value.(error); failed || cache.options.Store == nil {
+		return
+	}
+	raw, err := json.Marshal(value)
+	if err == nil {
+		err = cache.options.Store.Put(CacheRecord{Key: key, Value: raw, FetchedAt: now})
+	}
+	if err != nil {
+		cache.mu.Lock()
+		cache.storeErrors++
+		cache.mu.Unlock()
+	}
+}
+
+// cloneValue copies the slices of a cached IP or Domain, so neither the cache nor its
+// callers see each other's changes to them.
+func cloneValue(value any) any {
+	switch v := value.(type) {
+	case IP:
+		v.Intelligence.Behavior = slices.Clone(v.Intelligence.Behavior)
+		v.Intelligence.Categories = slices.Clone(v.Intelligence.Categories)
+		v.Intelligence.Devices = slices.Clone(v.Intelligence.Devices)
+		v.Intelligence.Providers = slices.Clone(v.Intelligence.Providers)
+		return v
+	case Domain:
+		v.TimeSeries = slices.Clone(v.TimeSeries)
+		v.UniqueIPs.Sparkline24H = slices.Clone(v.UniqueIPs.Sparkline24H)
+		v.TopSubdomains = slices.Clone(v.TopSubdomains)
+		v.TopPorts = slices.Clone(v.TopPorts)
+		v.GeoDistribution = slices.Clone(v.GeoDistribution)
+		v.HourDowHeatmap = slices.Clone(v.HourDowHeatmap)
+		for i, row := range v.HourDowHeatmap {
+			v.HourDowHeatmap[i] = slices.Clone(row)
+		}
+		v.RecentEvents = slices.Clone(v.RecentEvents)
+		return v
+	default:
+		return value
+	}
+}
+
+func (cache *LookupCache) insertLocked(entry *cacheEntry) {
+	if element, ok := cache.entries[entry.key]; ok {
+		element.Value = entry
+		cache.order.MoveToFront(element)
+		return
+	}
+	cache.entries[entry.key] = cache.order.PushFront(entry)
+	for cache.order.Len() > cache.options.MaxEntries {
+		cache.removeLocked(cache.order.Back())
+		cache.evictions++
+	}
+}
+
+func (cache *LookupCache) removeLocked(element *list.Element) {
+	cache.order.Remove(element)
+	delete(cache.entries, element.Value.(*cacheEntry).key)
+}
+
+// cachedLookup returns the cached result for key, or runs lookup and caches its
+// result.
+func cachedLookup[T any](cache *LookupCache, key string, ttl time.Duration, lookup func() (T, error)) (T, error) {
+	if value, ok := cache.get(key); ok {
+		if err, ok := value.(error); ok {
+			var zero T
+			return zero, err
+		}
+		return value.(T), nil
+	}
+
+	result, err := lookup()
+	switch {
+	case err == nil:
+		cache.put(key, result, ttl)
+	case cache.options.NegativeTTL > 0 && errors.Is(err, ErrBadRequest):
+		cache.put(key, err, cache.options.NegativeTTL)
+	}
+	return result, err
+}
+
+// cachedIPs splits ips into results already cached (by index) and the addresses still
+// to look up. A cached negative entry fails the whole batch, as the server would.
+func (cache *LookupCache) cachedIPs(ips []string) (map[int]IP, []string, error) {
+	cached := map[int]IP{}
+	var misses []string
+	for i, ip := range ips {
+		value, ok := cache.get(ipCacheKey(ip))
+		if !ok {
+			misses = append(misses, ip)
+			continue
+		}
+		if err, ok := value.(error); ok {
+			return nil, nil, err
+		}
+		cached[i] = value.(IP)
+	}
+	return cached, misses, nil
+}
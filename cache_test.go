@@ -0,0 +1,130 @@
+package synthient
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLookupCacheGetIPs(t *testing.T) {
+	var sent [][]string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ip, ok := strings.CutPrefix(r.URL.Path, "/lookup/ip/"); ok {
+			sent = append(sent, []string{ip})
+			_ = json.NewEncoder(w).Encode(IP{IP: ip})
+			return
+		}
+		var body struct {
+			IPs []string `json:"ips"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		sent = append(sent, body.IPs)
+		// reversed, as results are matched by address rather than position
+		results := make([]IP, len(body.IPs))
+		for i, ip := range body.IPs {
+			results[len(results)-1-i].IP = ip
+		}
+		_ = json.NewEncoder(w).Encode(map[string][]IP{"results": results})
+	}))
+	client.Cache = NewLookupCache(CacheOptions{})
+
+	_, err := client.GetIP("1.1.1.1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ips, err := client.GetIPs([]string{"8.8.8.8", "1.1.1.1", "9.9.9.9"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []string{ips[0].IP, ips[1].IP, ips[2].IP}
+	if !slices.Equal(got, []string{"8.8.8.8", "1.1.1.1", "9.9.9.9"}) {
+		t.Errorf("GetIPs order = %v", got)
+	}
+	if !slices.Equal(sent[1], []string{"8.8.8.8", "9.9.9.9"}) {
+		t.Errorf("batch sent %v, want only the misses", sent[1])
+	}
+
+	_, err = client.GetIPs([]string{"9.9.9.9", "8.8.8.8"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 2 {
+		t.Errorf("%d requests sent, want 2", len(sent))
+	}
+	stats := client.Cache.Stats()
+	if stats.Hits != 3 || stats.Misses != 3 || stats.Entries != 3 {
+		t.Errorf("Stats() = %+v, want 3 hits, 3 misses, 3 entries", stats)
+	}
+}
+
+func TestLookupCacheCopiesSlices(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"ip":"8.8.8.8","intelligence":{"categories":["VPN"],"providers":[{"provider":"alpha"}]}}`))
+	}))
+	client.Cache = NewLookupCache(CacheOptions{})
+
+	first, err := client.GetIP("8.8.8.8", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first.Intelligence.Categories[0] = "TOR"
+	first.Intelligence.Providers[0].Provider = "changed"
+
+	second, err := client.GetIP("8.8.8.8", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Intelligence.Categories[0] != "VPN" || second.Intelligence.Providers[0].Provider != "alpha" {
+		t.Errorf("cached result changed by the caller: %+v", second.Intelligence)
+	}
+	second.Intelligence.Categories[0] = "HOSTING"
+	third, _ := client.GetIP("8.8.8.8", nil)
+	if third.Intelligence.Categories[0] != "VPN" {
+		t.Errorf("cached result changed through a hit: %v", third.Intelligence.Categories)
+	}
+}
+
+func TestLookupCacheExpiryAndEviction(t *testing.T) {
+	calls := 0
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		name := strings.TrimPrefix(r.URL.Path, "/lookup/domain/")
+		if !strings.Contains(name, ".") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(Domain{Domain: name})
+	}))
+	client.Cache = NewLookupCache(CacheOptions{
+		MaxEntries:  2,
+		DomainTTL:   20 * time.Millisecond,
+		NegativeTTL: time.Minute,
+	})
+
+	for range 2 {
+		_, err := client.GetDomain("invalid", nil)
+		if !errors.Is(err, ErrBadRequest) {
+			t.Fatalf("err = %v, want ErrBadRequest", err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("%d requests for a negatively cached domain, want 1", calls)
+	}
+
+	_, _ = client.GetDomain("a.example", nil)
+	_, _ = client.GetDomain("b.example", nil)
+	if stats := client.Cache.Stats(); stats.Evictions != 1 || stats.Entries != 2 {
+		t.Errorf("Stats() = %+v, want 1 eviction and 2 entries", stats)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	calls = 0
+	_, _ = client.GetDomain("B.example", nil)
+	if calls != 1 {
+		t.Errorf("%d requests for an expired domain, want 1", calls)
+	}
+}
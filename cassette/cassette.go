@@ -0,0 +1,420 @@
+// Package cassette provides a record/replay http.RoundTripper for deterministic
+// integration tests against the Synthient API.
+//
+// In record mode a Recorder forwards requests to a real transport and captures every
+// exchange, including the 307 redirects behind snapshot downloads and the chunk
+// boundaries of NDJSON stream bodies. Credentials (X-Api-Key, Authorization and
+// cookies) are scrubbed before anything is stored. In replay mode the stored
+// responses are served without touching the network, with stream bodies played back
+// chunk by chunk so iterators such as Client.StreamProxy behave as they do live.
+//
+// Example:
+//
+//	mode := cassette.ModeReplay
+//	if os.Getenv("RECORD") != "" {
+//		mode = cassette.ModeRecord
+//	}
+//	recorder, err := cassette.New("testdata/lookup.json", mode)
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	defer recorder.Close()
+//
+//	client := synthient.NewClient(os.Getenv("SYNTHIENT_API_KEY"),
+//		synthient.WithHTTPClient(&http.Client{Transport: recorder}))
+package cassette
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrNoInteraction is returned in replay mode when no recorded interaction matches a
+// request.
+var ErrNoInteraction = errors.New("no recorded interaction matches request")
+
+// Mode selects whether a Recorder records or replays.
+type Mode int
+
+const (
+	// ModeReplay serves recorded interactions and never uses the network.
+	ModeReplay Mode = iota
+	// ModeRecord forwards every request and overwrites the cassette on Close.
+	ModeRecord
+	// ModeAuto replays when the cassette file exists and records otherwise.
+	ModeAuto
+)
+
+// Scrubbed replaces credential header values in stored cassettes.
+const Scrubbed = "REDACTED"
+
+// Cassette is the on-disk format: an ordered list of interactions.
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Interaction is one recorded HTTP exchange.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is the recorded request.
+type Request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// Response is the recorded response. Body is stored as the chunks the client read,
+// in order.
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Chunks     []Chunk     `json:"chunks,omitempty"`
+}
+
+// Chunk is one piece of a response body. Text holds UTF-8 data; anything else is
+// stored in Binary (base64 in JSON).
+type Chunk struct {
+	Text   string `json:"text,omitempty"`
+	Binary []byte `json:"binary,omitempty"`
+}
+
+func newChunk(data []byte) Chunk {
+	if utf8.Valid(data) {
+		return Chunk{Text: string(data)}
+	}
+	return Chunk{Binary: bytes.Clone(data)}
+}
+
+func (chunk Chunk) bytes() []byte {
+	if chunk.Binary != nil {
+		return chunk.Binary
+	}
+	return []byte(chunk.Text)
+}
+
+// Matcher reports whether a recorded request matches an outgoing one.
+type Matcher func(req *http.Request, body []byte, recorded Request) bool
+
+// MatchMethodAndURL matches on method, full URL and request body. It is the default.
+func MatchMethodAndURL(req *http.Request, body []byte, recorded Request) bool {
+	return req.Method == recorded.Method && req.URL.String() == recorded.URL && string(body) == recorded.Body
+}
+
+// MatchMethodAndPath matches on method, URL path and request body, ignoring the
+// scheme, host and query string. Use it when the base URL or presigned query
+// parameters differ between recording and replay.
+func MatchMethodAndPath(req *http.Request, body []byte, recorded Request) bool {
+	if req.Method != recorded.Method || string(body) != recorded.Body {
+		return false
+	}
+	u, err := url.Parse(recorded.URL)
+	return err == nil && u.Path == req.URL.Path
+}
+
+// Option configures a Recorder.
+type Option func(*Recorder)
+
+// WithTransport sets the transport used in record mode. Defaults to
+// http.DefaultTransport.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(recorder *Recorder) {
+		recorder.transport = transport
+	}
+}
+
+// WithMatcher sets how requests are matched during replay. Defaults to
+// MatchMethodAndURL.
+func WithMatcher(matcher Matcher) Option {
+	return func(recorder *Recorder) {
+		recorder.matcher = matcher
+	}
+}
+
+// WithChunkDelay pauses between body chunks during replay to mimic a live stream.
+func WithChunkDelay(delay time.Duration) Option {
+	return func(recorder *Recorder) {
+		recorder.chunkDelay = delay
+	}
+}
+
+// WithRepeats lets a recorded interaction be replayed more than once. By default each
+// interaction is served once, in recorded order among equal matches.
+func WithRepeats() Option {
+	return func(recorder *Recorder) {
+		recorder.repeats = true
+	}
+}
+
+// WithScrubHeaders adds headers whose values are replaced with Scrubbed before the
+// cassette is stored.
+func WithScrubHeaders(headers ...string) Option {
+	return func(recorder *Recorder) {
+		recorder.scrub = append(recorder.scrub, headers...)
+	}
+}
+
+// Recorder is an http.RoundTripper that records or replays a cassette file. It is
+// safe for concurrent use.
+type Recorder struct {
+	path       string
+	mode       Mode
+	transport  http.RoundTripper
+	matcher    Matcher
+	chunkDelay time.Duration
+	repeats    bool
+	scrub      []string
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+// New returns a Recorder for the cassette at path. In replay mode (and in ModeAuto
+// when the file exists) the cassette is loaded immediately.
+func New(path string, mode Mode, options ...Option) (*Recorder, error) {
+	recorder := &Recorder{
+		path:      path,
+		mode:      mode,
+		transport: http.DefaultTransport,
+		matcher:   MatchMethodAndURL,
+		scrub:     []string{"X-Api-Key", "Authorization", "Cookie", "Set-Cookie"},
+	}
+	for _, option := range options {
+		option(recorder)
+	}
+
+	if recorder.mode == ModeAuto {
+		recorder.mode = ModeRecord
+		if _, err := os.Stat(path); err == nil {
+			recorder.mode = ModeReplay
+		}
+	}
+	if recorder.mode == ModeReplay {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading cassette %s: %w", path, err)
+		}
+		err = json.Unmarshal(data, &recorder.cassette)
+		if err != nil {
+			return nil, fmt.Errorf("parsing cassette %s: %w", path, err)
+		}
+		recorder.used = make([]bool, len(recorder.cassette.Interactions))
+	}
+	return recorder, nil
+}
+
+// Mode returns the effective mode, resolving ModeAuto.
+func (recorder *Recorder) Mode() Mode {
+	return recorder.mode
+}
+
+// RoundTrip implements http.RoundTripper.
+func (recorder *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("reading request body: %w", err)
+		}
+	}
+	if recorder.mode == ModeReplay {
+		return recorder.replay(req, body)
+	}
+	return recorder.record(req, body)
+}
+
+func (recorder *Recorder) record(req *http.Request, body []byte) (*http.Response, error) {
+	forwarded := req.Clone(req.Context())
+	if req.Body != nil {
+		forwarded.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	resp, err := recorder.transport.RoundTrip(forwarded)
+	if err != nil {
+		return nil, err
+	}
+
+	interaction := &Interaction{
+		Request: Request{
+			Method: req.Method,
+			URL:    req.URL.String(),
+			Header: recorder.scrubbed(req.Header),
+			Body:   string(body),
+		},
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Header:     recorder.scrubbed(resp.Header),
+		},
+	}
+	recorder.mu.Lock()
+	recorder.cassette.Interactions = append(recorder.cassette.Interactions, interaction)
+	recorder.mu.Unlock()
+
+	resp.Body = &recordingBody{ReadCloser: resp.Body, recorder: recorder, interaction: interaction}
+	return resp, nil
+}
+
+func (recorder *Recorder) replay(req *http.Request, body []byte) (*http.Response, error) {
+	recorder.mu.Lock()
+	var found *Interaction
+	for i, interaction := range recorder.cassette.Interactions {
+		if recorder.used[i] && !recorder.repeats {
+			continue
+		}
+		if recorder.matcher(req, body, interaction.Request) {
+			recorder.used[i] = true
+			found = interaction
+			break
+		}
+	}
+	recorder.mu.Unlock()
+	if found == nil {
+		return nil, fmt.Errorf("%s %s: %w", req.Method, req.URL, ErrNoInteraction)
+	}
+
+	chunks := make([][]byte, 0, len(found.Response.Chunks))
+	length := int64(0)
+	for _, chunk := range found.Response.Chunks {
+		data := chunk.bytes()
+		chunks = append(chunks, data)
+		length += int64(len(data))
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", found.Response.StatusCode, http.StatusText(found.Response.StatusCode)),
+		StatusCode:    found.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        found.Response.Header.Clone(),
+		Body:          &replayBody{chunks: chunks, delay: recorder.chunkDelay, ctx: req.Context()},
+		ContentLength: length,
+		Request:       req,
+	}, nil
+}
+
+// Interactions returns the interactions recorded or loaded so far.
+func (recorder *Recorder) Interactions() []*Interaction {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	return append([]*Interaction(nil), recorder.cassette.Interactions...)
+}
+
+// Close writes the cassette to disk in record mode. It is a no-op in replay mode.
+// Close bodies of recorded responses first so their chunks are complete.
+func (recorder *Recorder) Close() error {
+	if recorder.mode != ModeRecord {
+		return nil
+	}
+	recorder.mu.Lock()
+	data, err := json.MarshalIndent(recorder.cassette, "", "  ")
+	recorder.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("encoding cassette: %w", err)
+	}
+
+	err = os.MkdirAll(filepath.Dir(recorder.path), 0o755)
+	if err != nil {
+		return fmt.Errorf("creating cassette directory: %w", err)
+	}
+	tmp := recorder.path + ".tmp"
+	err = os.WriteFile(tmp, append(data, '\n'), 0o644)
+	if err != nil {
+		return fmt.Errorf("writing cassette %s: %w", recorder.path, err)
+	}
+	err = os.Rename(tmp, recorder.path)
+	if err != nil {
+		return fmt.Errorf("writing cassette %s: %w", recorder.path, err)
+	}
+	return nil
+}
+
+func (recorder *Recorder) scrubbed(header http.Header) http.Header {
+	header = header.Clone()
+	for _, key := range recorder.scrub {
+		if _, ok := header[http.CanonicalHeaderKey(key)]; ok {
+			header.Set(key, Scrubbed)
+		}
+	}
+	// nil values (e.g. Idempotency-Key markers) are never sent, so they are not kept
+	for key, values := range header {
+		if values == nil {
+			delete(header, key)
+		}
+	}
+	return header
+}
+
+// recordingBody captures each chunk the client reads from a live response.
+type recordingBody struct {
+	io.ReadCloser
+	recorder    *Recorder
+	interaction *Interaction
+}
+
+func (body *recordingBody) Read(p []byte) (int, error) {
+	n, err := body.ReadCloser.Read(p)
+	if n > 0 {
+		body.recorder.mu.Lock()
+		body.interaction.Response.Chunks = append(body.interaction.Response.Chunks, newChunk(p[:n]))
+		body.recorder.mu.Unlock()
+	}
+	return n, err
+}
+
+// replayBody serves recorded chunks, one chunk (or part of one) per Read, pausing for
+// delay before every chunk after the first.
+type replayBody struct {
+	chunks  [][]byte
+	delay   time.Duration
+	ctx     context.Context
+	offset  int
+	started bool
+	closed  bool
+}
+
+func (body *replayBody) Read(p []byte) (int, error) {
+	if body.closed {
+		return 0, errors.New("read on closed body")
+	}
+	if len(body.chunks) == 0 {
+		return 0, io.EOF
+	}
+	if body.offset == 0 && body.started && body.delay > 0 {
+		timer := time.NewTimer(body.delay)
+		select {
+		case <-body.ctx.Done():
+			timer.Stop()
+			return 0, body.ctx.Err()
+		case <-timer.C:
+		}
+	}
+	body.started = true
+	n := copy(p, body.chunks[0][body.offset:])
+	body.offset += n
+	if body.offset == len(body.chunks[0]) {
+		body.chunks = body.chunks[1:]
+		body.offset = 0
+	}
+	return n, nil
+}
+
+func (body *replayBody) Close() error {
+	body.closed = true
+	return nil
+}
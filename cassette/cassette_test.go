@@ -0,0 +1,96 @@
+package cassette
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/synthient/go-synthient/v2"
+	"github.com/synthient/go-synthient/v2/synthienttest"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	server := synthienttest.NewServer()
+	var record synthient.IP
+	record.IP = "213.149.183.127"
+	record.Intelligence.RiskScore = 75
+	server.AddIP(record)
+	server.AddSnapshot("proxies", synthienttest.Snapshot{Date: "2026-05-07", Data: []byte("PAR1\x00\xffPAR1")})
+	server.Push("proxies", synthient.ProxyEvent{IP: "198.51.100.1"}, synthient.ProxyEvent{IP: "198.51.100.2"})
+	server.CloseStream("proxies")
+
+	recorder, err := New(path, ModeRecord, WithTransport(server.Client().HttpClient.Transport))
+	if err != nil {
+		t.Fatal(err)
+	}
+	exercise(t, server.Client(synthient.WithHTTPClient(&http.Client{Transport: recorder})))
+	err = recorder.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := server.BaseURL()
+	server.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), synthienttest.Token) {
+		t.Fatal("cassette contains the API key")
+	}
+
+	replayer, err := New(path, ModeAuto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replayer.Mode() != ModeReplay {
+		t.Fatalf("ModeAuto resolved to %d with an existing cassette", replayer.Mode())
+	}
+	client := synthient.NewClient("any-key",
+		synthient.WithHTTPClient(&http.Client{Transport: replayer}),
+		synthient.WithBaseAPI(base),
+	)
+	exercise(t, client)
+
+	_, err = client.GetIP("213.149.183.127", nil)
+	if !errors.Is(err, ErrNoInteraction) {
+		t.Errorf("err = %v, want ErrNoInteraction once interactions are used up", err)
+	}
+}
+
+func exercise(t *testing.T, client synthient.Client) {
+	t.Helper()
+	ip, err := client.GetIP("213.149.183.127", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ip.Intelligence.RiskScore != 75 {
+		t.Errorf("RiskScore = %d, want 75", ip.Intelligence.RiskScore)
+	}
+
+	r, err := client.DownloadProxy("2026-05-07", nil, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(r)
+	_ = r.Close()
+	if err != nil || string(body) != "PAR1\x00\xffPAR1" {
+		t.Errorf("download = %q, %v", body, err)
+	}
+
+	events := 0
+	for _, err := range client.StreamProxy(nil) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		events++
+	}
+	if events != 2 {
+		t.Errorf("stream yielded %d events, want 2", events)
+	}
+}
@@ -1,10 +1,18 @@
 package synthient
 
 import (
+	"fmt"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"os"
+	"strings"
+	"time"
 )
 
+// DefaultUserAgent is the User-Agent header NewClient configures.
+const DefaultUserAgent = "go-synthient/v2"
+
 // Client is a Synthient API client.
 //
 // It holds the HTTP transport and configuration required to make API and feed
@@ -15,33 +23,136 @@ import (
 //     the package may fall back to http.DefaultClient (depending on request
 //     helpers).
 //   - Token is the API token used for authentication.
+//   - Tokens, when non-nil, is consulted for the API token on every request instead
+//     of Token, which allows keys to be rotated without rebuilding the client.
 //   - BaseAPI is the base URL for JSON API endpoints (e.g. lookups).
 //   - BaseFeeds is the base URL for feed endpoints that may return large,
 //     streamable payloads (e.g. CSV feeds).
+//   - GRPCEndpoint is the gRPC server used by GRPCSchema when its options do not
+//     name one. If empty, DefaultGRPCEndpoint is used.
+//   - UserAgent is sent as the User-Agent header on every request. If empty, the
+//     net/http default is sent.
+//   - Retry configures automatic retries of failed idempotent requests. If nil,
+//     every request is attempted exactly once.
+//   - Limiter tracks lookup credits client-side so lookups stop before the quota
+//     runs out. If nil, lookups are not limited.
+//   - Cache, when non-nil, serves repeated GetIP, GetIPs and GetDomain lookups from
+//     memory instead of the API.
+//   - Middleware wraps every API, stream and download call, outermost first.
+//   - Logger receives structured logs for requests, redirects, retries, stream
+//     connects and disconnects, and decode failures. If nil, nothing is logged.
+//     The X-Api-Key header is always redacted.
+//   - MaskSubjects masks looked-up IPs and domains in logs (see MaskSubject).
+//
+// Clients built by NewClient share concurrent identical GetIP and GetDomain lookups
+// between callers, including across copies of the Client; see WithoutCoalescing.
 type Client struct {
-	HttpClient *http.Client
-	Token      string
-	BaseAPI    url.URL
-	BaseFeeds  url.URL
+	HttpClient   *http.Client
+	Token        string
+	Tokens       TokenProvider
+	BaseAPI      url.URL
+	BaseFeeds    url.URL
+	GRPCEndpoint string
+	UserAgent    string
+	Retry        *RetryPolicy
+	Limiter      *QuotaLimiter
+	Cache        *LookupCache
+	Middleware   []Middleware
+	Logger       *slog.Logger
+	MaskSubjects bool
+
+	flights *flightGroup
+}
+
+// Option configures a Client built by NewClient or NewClientFromEnv. Options are
+// applied in order, so later options override earlier ones.
+type Option func(*Client)
+
+// WithHTTPClient sets the underlying HTTP client. Pass it before WithTimeout when
+// combining the two.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(client *Client) {
+		client.HttpClient = httpClient
+	}
+}
+
+// WithTimeout sets the overall timeout of every request, including reading the
+// response body. The HTTP client is copied rather than modified in place. Streams run
+// until the connection closes, so keep this at zero for clients used with Stream*
+// methods and use RequestOptions.Context instead.
+func WithTimeout(timeout time.Duration) Option {
+	return func(client *Client) {
+		httpClient := http.Client{}
+		if client.HttpClient != nil {
+			httpClient = *client.HttpClient
+		}
+		httpClient.Timeout = timeout
+		client.HttpClient = &httpClient
+	}
+}
+
+// WithBaseAPI overrides the base URL for JSON API endpoints.
+func WithBaseAPI(base url.URL) Option {
+	return func(client *Client) {
+		client.BaseAPI = base
+	}
+}
+
+// WithBaseFeeds overrides the base URL for feed endpoints.
+func WithBaseFeeds(base url.URL) Option {
+	return func(client *Client) {
+		client.BaseFeeds = base
+	}
+}
+
+// WithGRPCEndpoint overrides the gRPC server used by GRPCSchema.
+func WithGRPCEndpoint(endpoint string) Option {
+	return func(client *Client) {
+		client.GRPCEndpoint = endpoint
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent on every request.
+func WithUserAgent(userAgent string) Option {
+	return func(client *Client) {
+		client.UserAgent = userAgent
+	}
+}
+
+// WithRetry sets the retry policy for failed idempotent requests.
+func WithRetry(policy *RetryPolicy) Option {
+	return func(client *Client) {
+		client.Retry = policy
+	}
+}
+
+// WithLimiter attaches a QuotaLimiter shared by every lookup made through the client.
+func WithLimiter(limiter *QuotaLimiter) Option {
+	return func(client *Client) {
+		client.Limiter = limiter
+	}
 }
 
 // NewClient constructs a Client configured for the Synthient v3 API.
 //
 // The returned client is initialized with:
 //   - a new *http.Client as the underlying transport,
-//   - the provided token for authentication, and
-//   - default base URLs for the JSON API (BaseAPI) and feeds service (BaseFeeds).
+//   - the provided token for authentication,
+//   - default base URLs for the JSON API (BaseAPI) and feeds service (BaseFeeds), and
+//   - DefaultUserAgent as its User-Agent,
 //
-// If you need custom timeouts, proxies, or transports, modify c.HttpClient after
-// construction. If Synthient endpoints differ for your environment, you may also
-// override BaseAPI and/or BaseFeeds.
+// after which options are applied in order. Retries are disabled unless WithRetry is
+// passed, for example with DefaultRetryPolicy().
 //
 // Example:
 //
-//	c := synthient.NewClient(os.Getenv("SYNTHIENT_API_KEY"))
-//	c.HttpClient.Timeout = 30 * time.Second
-func NewClient(token string) Client {
-	return Client{
+//	c := synthient.NewClient(
+//		os.Getenv("SYNTHIENT_API_KEY"),
+//		synthient.WithTimeout(30*time.Second),
+//		synthient.WithRetry(synthient.DefaultRetryPolicy()),
+//	)
+func NewClient(token string, options ...Option) Client {
+	client := Client{
 		HttpClient: &http.Client{},
 		Token:      token,
 		BaseAPI: url.URL{
@@ -54,5 +165,74 @@ func NewClient(token string) Client {
 			Host:   "feeds.synthient.com",
 			Path:   "/v3",
 		},
+		UserAgent: DefaultUserAgent,
+		flights:   newFlightGroup(),
+	}
+	for _, option := range options {
+		option(&client)
+	}
+	return client
+}
+
+// NewClientFromEnv constructs a Client from environment variables:
+//
+//   - SYNTHIENT_API_KEY (required) is the API token.
+//   - SYNTHIENT_BASE_API overrides BaseAPI.
+//   - SYNTHIENT_BASE_FEEDS overrides BaseFeeds.
+//   - SYNTHIENT_GRPC_ENDPOINT overrides GRPCEndpoint.
+//
+// URLs and the gRPC endpoint are validated up front, so misconfiguration is reported
+// here instead of on the first request. options are applied after the environment.
+//
+// Example:
+//
+//	client, err := synthient.NewClientFromEnv(synthient.WithTimeout(30 * time.Second))
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+func NewClientFromEnv(options ...Option) (Client, error) {
+	token := strings.TrimSpace(os.Getenv("SYNTHIENT_API_KEY"))
+	if token == "" {
+		return Client{}, fmt.Errorf("reading SYNTHIENT_API_KEY: %w", ErrNoToken)
+	}
+
+	envOptions := []Option{}
+	if raw := os.Getenv("SYNTHIENT_BASE_API"); raw != "" {
+		base, err := parseBaseURL(raw)
+		if err != nil {
+			return Client{}, fmt.Errorf("parsing SYNTHIENT_BASE_API: %w", err)
+		}
+		envOptions = append(envOptions, WithBaseAPI(base))
+	}
+	if raw := os.Getenv("SYNTHIENT_BASE_FEEDS"); raw != "" {
+		base, err := parseBaseURL(raw)
+		if err != nil {
+			return Client{}, fmt.Errorf("parsing SYNTHIENT_BASE_FEEDS: %w", err)
+		}
+		envOptions = append(envOptions, WithBaseFeeds(base))
+	}
+	if raw := os.Getenv("SYNTHIENT_GRPC_ENDPOINT"); raw != "" {
+		endpoint, _, err := NormalizeGRPCEndpoint(raw)
+		if err != nil {
+			return Client{}, fmt.Errorf("parsing SYNTHIENT_GRPC_ENDPOINT: %w", err)
+		}
+		envOptions = append(envOptions, WithGRPCEndpoint(endpoint))
+	}
+
+	return NewClient(token, append(envOptions, options...)...), nil
+}
+
+// parseBaseURL parses an absolute http(s) base URL.
+func parseBaseURL(raw string) (url.URL, error) {
+	parsed, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return url.URL{}, err
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return url.URL{}, fmt.Errorf("unsupported scheme %q, expected http or https", parsed.Scheme)
+	}
+	if parsed.Host == "" {
+		return url.URL{}, fmt.Errorf("missing host in %q", raw)
 	}
+	return *parsed, nil
 }
@@ -0,0 +1,57 @@
+package synthient
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestNewClientOptions(t *testing.T) {
+	shared := &http.Client{}
+	client := NewClient(
+		"token",
+		WithHTTPClient(shared),
+		WithTimeout(5*time.Second),
+		WithUserAgent("svc/1.0"),
+		WithRetry(DefaultRetryPolicy()),
+	)
+	if client.HttpClient == shared || shared.Timeout != 0 {
+		t.Error("WithTimeout modified the caller's http.Client")
+	}
+	if client.HttpClient.Timeout != 5*time.Second {
+		t.Errorf("timeout = %s, want 5s", client.HttpClient.Timeout)
+	}
+	if client.UserAgent != "svc/1.0" || client.Retry == nil {
+		t.Errorf("options not applied: %+v", client)
+	}
+}
+
+func TestNewClientFromEnv(t *testing.T) {
+	t.Setenv("SYNTHIENT_API_KEY", "secret")
+	t.Setenv("SYNTHIENT_BASE_API", "http://localhost:8080/api/v4")
+	t.Setenv("SYNTHIENT_GRPC_ENDPOINT", "grpc://localhost:9090")
+
+	client, err := NewClientFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.Token != "secret" || client.BaseAPI.Host != "localhost:8080" {
+		t.Errorf("client = %+v", client)
+	}
+	if client.GRPCEndpoint != "localhost:9090" {
+		t.Errorf("GRPCEndpoint = %q", client.GRPCEndpoint)
+	}
+
+	t.Setenv("SYNTHIENT_BASE_FEEDS", "feeds.synthient.com/v3")
+	_, err = NewClientFromEnv()
+	if err == nil {
+		t.Error("NewClientFromEnv accepted a base URL without a scheme")
+	}
+
+	t.Setenv("SYNTHIENT_API_KEY", "")
+	_, err = NewClientFromEnv()
+	if !errors.Is(err, ErrNoToken) {
+		t.Errorf("err = %v, want ErrNoToken", err)
+	}
+}
@@ -0,0 +1,161 @@
+// Command synthient-enrich joins Synthient IP intelligence into access logs.
+//
+// It reads a Common, Combined or JSON-lines log, looks up every distinct client IP and
+// writes JSONL or CSV with the selected IP fields added to each line:
+//
+//	synthient-enrich -format combined -output csv -o enriched.csv /var/log/nginx/access.log
+//	zcat access.log.gz | synthient-enrich -format json -ip-field client.ip > enriched.jsonl
+//
+// The client is configured from the environment as by synthient.NewClientFromEnv;
+// SYNTHIENT_API_KEY is required. Lookups are checkpointed in a state file (by default
+// the output path with ".state" appended, when -o is given), so a run that is
+// interrupted or fails can be repeated without paying for the same lookups twice. The
+// output file is written atomically once every lookup has completed.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/synthient/go-synthient/v2"
+	"github.com/synthient/go-synthient/v2/diskcache"
+	"github.com/synthient/go-synthient/v2/enrich"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("synthient-enrich: ")
+
+	var options enrich.Options
+	var format, output, fieldList, outPath, statePath string
+	flag.StringVar(&format, "format", "", "log format: common, combined or json (default: detect)")
+	flag.StringVar(&options.IPField, "ip-field", enrich.DefaultIPField, "JSON field holding the client IP, dotted for nested objects")
+	flag.StringVar(&output, "output", string(enrich.OutputJSONL), "output format: jsonl or csv")
+	flag.StringVar(&fieldList, "fields", strings.Join(enrich.DefaultFields, ","), "comma-separated IP fields to add: "+strings.Join(enrich.Fields(), ", "))
+	flag.StringVar(&options.Prefix, "prefix", enrich.DefaultPrefix, "prefix for added field names")
+	flag.IntVar(&options.BatchSize, "batch", enrich.DefaultBatchSize, "IPs per lookup request")
+	flag.StringVar(&outPath, "o", "", "output file (default: stdout)")
+	flag.StringVar(&statePath, "state", "", "checkpoint file for resuming (default: <output>.state when -o is set)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: synthient-enrich [flags] [log file]\n\nReads standard input when no file or \"-\" is given.\n\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	options.Format = enrich.Format(format)
+	options.Output = enrich.Output(output)
+	options.Fields = strings.Split(fieldList, ",")
+	if statePath == "" && outPath != "" {
+		statePath = outPath + ".state"
+	}
+
+	err := run(flag.Arg(0), outPath, statePath, &options)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(inPath, outPath, statePath string, options *enrich.Options) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	client, err := synthient.NewClientFromEnv(synthient.WithRetry(synthient.DefaultRetryPolicy()))
+	if err != nil {
+		return err
+	}
+
+	in, err := openInput(inPath)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if statePath != "" {
+		state, err := diskcache.Open(statePath, diskcache.Options{})
+		if err != nil {
+			return err
+		}
+		defer state.Close()
+		options.State = state
+	}
+	options.Progress = func(done, total int) {
+		log.Printf("resolved %d/%d ips", done, total)
+	}
+
+	out, commit, err := createOutput(outPath)
+	if err != nil {
+		return err
+	}
+	stats, err := enrich.Run(ctx, &client, in, out, options)
+	err = commit(err)
+	if err != nil {
+		if errors.Is(err, context.Canceled) && statePath != "" {
+			log.Printf("interrupted; rerun with the same state file to resume")
+		}
+		return err
+	}
+	log.Printf("%d lines, %d enriched, %d unparsed; %d unique ips, %d looked up, %d resumed, %d without results",
+		stats.Lines, stats.Enriched, stats.Unparsed, stats.UniqueIPs, stats.LookedUp, stats.Resumed, stats.Missing)
+	return nil
+}
+
+// openInput opens path, or spools standard input to a temporary file, since
+// enrich.Run reads its input twice.
+func openInput(path string) (*os.File, error) {
+	if path != "" && path != "-" {
+		return os.Open(path)
+	}
+	spool, err := os.CreateTemp("", "synthient-enrich-*")
+	if err != nil {
+		return nil, fmt.Errorf("spooling standard input: %w", err)
+	}
+	_ = os.Remove(spool.Name())
+	_, err = io.Copy(spool, os.Stdin)
+	if err == nil {
+		_, err = spool.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		spool.Close()
+		return nil, fmt.Errorf("spooling standard input: %w", err)
+	}
+	return spool, nil
+}
+
+// createOutput returns the writer for path (standard output when empty) and a commit
+// function that finishes it: a file is written to a temporary name and renamed into
+// place only when err is nil.
+func createOutput(path string) (io.Writer, func(err error) error, error) {
+	if path == "" {
+		return os.Stdout, func(err error) error { return err }, nil
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return nil, nil, fmt.Errorf("creating output: %w", err)
+	}
+	commit := func(err error) error {
+		closeErr := tmp.Close()
+		if err == nil && closeErr != nil {
+			err = fmt.Errorf("writing output: %w", closeErr)
+		}
+		if err == nil {
+			err = os.Rename(tmp.Name(), path)
+		}
+		if err != nil {
+			_ = os.Remove(tmp.Name())
+		}
+		return err
+	}
+	return tmp, commit, nil
+}
@@ -0,0 +1,113 @@
+package synthient
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+)
+
+// flightGroup tracks the GetIP and GetDomain lookups in flight, so concurrent callers
+// asking for the same subject share one request. It is shared by copies of a Client.
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flight
+}
+
+type flight struct {
+	done    chan struct{}
+	waiters int
+	cancel  context.CancelFunc
+
+	value any
+	err   error
+	meta  ResponseMeta
+}
+
+func newFlightGroup() *flightGroup {
+	return &flightGroup{calls: map[string]*flight{}}
+}
+
+// WithoutCoalescing disables the sharing of concurrent identical lookups, so every
+// GetIP and GetDomain call makes its own request.
+func WithoutCoalescing() Option {
+	return func(client *Client) {
+		client.flights = nil
+	}
+}
+
+// coalesce runs lookup for key unless an identical lookup is already in flight, in
+// which case it waits for that one's result. Lookups are only shared between callers
+// using the same API endpoint and key. The shared lookup runs with the values and
+// deadline of the context that started it but not its cancellation: a caller whose
+// context ends stops waiting, and the lookup itself is cancelled only once every
+// caller has gone.
+func coalesce[T any](
+	client *Client,
+	key string,
+	options *RequestOptions,
+	lookup func(options *RequestOptions) (T, error),
+) (T, error) {
+	group := client.flights
+	if group == nil {
+		return lookup(options)
+	}
+	ctx := options.context()
+	token, err := client.token(ctx)
+	if err != nil {
+		return lookup(options)
+	}
+	sum := sha256.Sum256([]byte(token))
+	key = client.BaseAPI.String() + " " + hex.EncodeToString(sum[:8]) + " " + key
+
+	group.mu.Lock()
+	f, ok := group.calls[key]
+	if !ok {
+		shared := context.WithoutCancel(ctx)
+		var cancel context.CancelFunc
+		if deadline, ok := ctx.Deadline(); ok {
+			shared, cancel = context.WithDeadline(shared, deadline)
+		} else {
+			shared, cancel = context.WithCancel(shared)
+		}
+		f = &flight{done: make(chan struct{}), cancel: cancel}
+		group.calls[key] = f
+		go func() {
+			defer cancel()
+			value, err := lookup(&RequestOptions{Context: shared, Response: &f.meta})
+			f.value, f.err = value, err
+			group.mu.Lock()
+			if group.calls[key] == f {
+				delete(group.calls, key)
+			}
+			group.mu.Unlock()
+			close(f.done)
+		}()
+	}
+	f.waiters++
+	group.mu.Unlock()
+
+	var zero T
+	select {
+	case <-f.done:
+		if options != nil && options.Response != nil && f.meta.StatusCode != 0 {
+			*options.Response = f.meta
+		}
+		if f.err != nil {
+			return zero, f.err
+		}
+		return f.value.(T), nil
+	case <-ctx.Done():
+		group.mu.Lock()
+		f.waiters--
+		if f.waiters == 0 {
+			f.cancel()
+			if group.calls[key] == f {
+				delete(group.calls, key)
+			}
+		}
+		group.mu.Unlock()
+		return zero, fmt.Errorf("waiting for in-flight lookup: %w", ctx.Err())
+	}
+}
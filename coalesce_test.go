@@ -0,0 +1,155 @@
+package synthient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newBlockingClient(t *testing.T, release <-chan struct{}, calls *atomic.Int32, cancelled *atomic.Bool) Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		select {
+		case <-release:
+		case <-r.Context().Done():
+			cancelled.Store(true)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(IP{IP: strings.TrimPrefix(r.URL.Path, "/lookup/ip/")})
+	}))
+	t.Cleanup(server.Close)
+	base, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewClient("test", WithHTTPClient(server.Client()), WithBaseAPI(*base))
+}
+
+func TestCoalesceIdenticalLookups(t *testing.T) {
+	release := make(chan struct{})
+	var calls atomic.Int32
+	var cancelled atomic.Bool
+	client := newBlockingClient(t, release, &calls, &cancelled)
+
+	var wg sync.WaitGroup
+	var failures atomic.Int32
+	for range 20 {
+		wg.Go(func() {
+			var meta ResponseMeta
+			_, err := client.GetIP("8.8.8.8", &RequestOptions{Response: &meta})
+			if err != nil || meta.StatusCode != http.StatusOK {
+				failures.Add(1)
+			}
+		})
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls.Load() != 1 {
+		t.Errorf("server called %d times, want 1", calls.Load())
+	}
+	if failures.Load() != 0 {
+		t.Errorf("%d callers failed", failures.Load())
+	}
+}
+
+func TestCoalesceCancelledCallerDropsOut(t *testing.T) {
+	release := make(chan struct{})
+	var calls atomic.Int32
+	var cancelled atomic.Bool
+	client := newBlockingClient(t, release, &calls, &cancelled)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := client.GetIP("1.1.1.1", &RequestOptions{Context: ctx})
+		first <- err
+	}()
+	second := make(chan error, 1)
+	go func() {
+		_, err := client.GetIP("1.1.1.1", nil)
+		second <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	cancel()
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled caller err = %v, want context.Canceled", err)
+	}
+	close(release)
+	if err := <-second; err != nil {
+		t.Fatalf("remaining caller err = %v", err)
+	}
+	if cancelled.Load() || calls.Load() != 1 {
+		t.Errorf("shared request cancelled=%v after %d calls", cancelled.Load(), calls.Load())
+	}
+}
+
+func TestCoalesceSeparatesTokens(t *testing.T) {
+	release := make(chan struct{})
+	var calls atomic.Int32
+	var cancelled atomic.Bool
+	client := newBlockingClient(t, release, &calls, &cancelled)
+	other := client
+	other.Token = "other"
+
+	var wg sync.WaitGroup
+	for _, c := range []*Client{&client, &other} {
+		wg.Go(func() {
+			_, err := c.GetIP("8.8.8.8", nil)
+			if err != nil {
+				t.Error(err)
+			}
+		})
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if calls.Load() != 2 {
+		t.Errorf("server called %d times, want one per API key", calls.Load())
+	}
+}
+
+func TestCoalesceKeepsLeaderDeadline(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	var calls atomic.Int32
+	var cancelled atomic.Bool
+	client := newBlockingClient(t, release, &calls, &cancelled)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	first := make(chan error, 1)
+	go func() {
+		_, err := client.GetIP("1.1.1.1", &RequestOptions{Context: ctx})
+		first <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	second := make(chan error, 1)
+	go func() {
+		_, err := client.GetIP("1.1.1.1", nil)
+		second <- err
+	}()
+
+	if err := <-first; !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("leader err = %v, want context.DeadlineExceeded", err)
+	}
+	select {
+	case err := <-second:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("follower err = %v, want context.DeadlineExceeded", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("shared lookup outlived the leader's deadline")
+	}
+}
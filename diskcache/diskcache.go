@@ -0,0 +1,297 @@
+// Package diskcache provides a file-backed synthient.CacheStore, so lookups paid for by
+// one run of a program are still cached after it restarts.
+//
+// A Store is an append-only log of JSON records, one per line, with an in-memory index
+// of the latest record for every key. Several processes on one host may open the same
+// file: a lock file next to it serializes writers, and each process picks up records
+// appended by the others before reading. Compact rewrites the log without superseded
+// and expired records.
+//
+//	store, err := diskcache.Open("/var/cache/myjob/synthient.log", diskcache.Options{MaxAge: 24 * time.Hour})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer store.Close()
+//
+//	cache := synthient.NewLookupCache(synthient.CacheOptions{IPTTL: 24 * time.Hour, Store: store})
+//	client := synthient.NewClient(os.Getenv("SYNTHIENT_API_KEY"), synthient.WithCache(cache))
+package diskcache
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/synthient/go-synthient/v2"
+)
+
+// Options configures a Store.
+type Options struct {
+	// MaxAge expires records fetched longer ago: Get no longer returns them and
+	// Compact drops them. Zero keeps records until they are replaced.
+	MaxAge time.Duration
+}
+
+// Store is a file-backed synthient.CacheStore. It is safe for concurrent use by
+// multiple goroutines and by multiple processes on the same host.
+//
+// Cross-process locking uses flock on Unix systems and LockFileEx on Windows. On other
+// platforms a Store only synchronizes within its own process.
+type Store struct {
+	path    string
+	options Options
+
+	mu     sync.Mutex
+	lock   *os.File
+	file   *os.File
+	offset int64 // end of the last complete record indexed
+	index  map[string]entry
+}
+
+type entry struct {
+	offset    int64
+	length    int
+	fetchedAt time.Time
+}
+
+// Open opens or creates the log at path, along with its lock file path+".lock".
+func Open(path string, options Options) (*Store, error) {
+	lock, err := os.OpenFile(path+".lock", os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("opening lock file: %w", err)
+	}
+	store := &Store{path: path, options: options, lock: lock}
+
+	err = store.locked(false, func() error { return store.reopen() })
+	if err != nil {
+		_ = lock.Close()
+		return nil, err
+	}
+	return store, nil
+}
+
+// Close closes the log. The Store must not be used afterwards.
+func (store *Store) Close() error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	var err error
+	if store.file != nil { // left closed by a Compact that could not reopen the log
+		err = store.file.Close()
+	}
+	return errors.Join(err, store.lock.Close())
+}
+
+// Len returns the number of keys in the log, including expired ones not yet compacted.
+func (store *Store) Len() int {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	return len(store.index)
+}
+
+// Get implements synthient.CacheStore.
+func (store *Store) Get(key string) (synthient.CacheRecord, bool, error) {
+	var record synthient.CacheRecord
+	var found bool
+	err := store.locked(false, func() error {
+		err := store.sync()
+		if err != nil {
+			return err
+		}
+		e, ok := store.index[key]
+		if !ok || store.expired(e.fetchedAt) {
+			return nil
+		}
+		record, err = store.read(e)
+		found = err == nil
+		return err
+	})
+	return record, found, err
+}
+
+// Put implements synthient.CacheStore.
+func (store *Store) Put(record synthient.CacheRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("encoding record: %w", err)
+	}
+	line = append(line, '\n')
+
+	return store.locked(true, func() error {
+		err := store.sync()
+		if err != nil {
+			return err
+		}
+		// drop a partial record left by a writer that crashed mid-append
+		info, err := store.file.Stat()
+		if err != nil {
+			return fmt.Errorf("reading log size: %w", err)
+		}
+		if info.Size() > store.offset {
+			err = store.file.Truncate(store.offset)
+			if err != nil {
+				return fmt.Errorf("truncating partial record: %w", err)
+			}
+		}
+
+		_, err = store.file.Write(line)
+		if err != nil {
+			return fmt.Errorf("appending record: %w", err)
+		}
+		store.index[record.Key] = entry{offset: store.offset, length: len(line), fetchedAt: record.FetchedAt}
+		store.offset += int64(len(line))
+		return nil
+	})
+}
+
+// Compact rewrites the log keeping only the latest record for each key, dropping
+// records older than Options.MaxAge. Other processes switch to the new file on their
+// next access. On Windows the log cannot be replaced while another process has it
+// open, so Compact fails there unless the Store is the only one using the log.
+func (store *Store) Compact() error {
+	return store.locked(true, func() error {
+		err := store.sync()
+		if err != nil {
+			return err
+		}
+
+		tmp, err := os.CreateTemp(filepath.Dir(store.path), ".diskcache-*")
+		if err != nil {
+			return fmt.Errorf("creating compacted log: %w", err)
+		}
+		defer func() { _ = os.Remove(tmp.Name()) }()
+
+		keys := make([]string, 0, len(store.index))
+		for key, e := range store.index {
+			if !store.expired(e.fetchedAt) {
+				keys = append(keys, key)
+			}
+		}
+		slices.Sort(keys)
+		w := bufio.NewWriter(tmp)
+		for _, key := range keys {
+			e := store.index[key]
+			line := make([]byte, e.length)
+			_, err = store.file.ReadAt(line, e.offset)
+			if err == nil {
+				_, err = w.Write(line)
+			}
+			if err != nil {
+				_ = tmp.Close()
+				return fmt.Errorf("copying record %s: %w", key, err)
+			}
+		}
+		err = errors.Join(w.Flush(), tmp.Sync(), tmp.Close())
+		if err != nil {
+			return fmt.Errorf("writing compacted log: %w", err)
+		}
+		// Windows cannot replace a file that is open, so the old log is closed first
+		// and reopened if it could not be replaced.
+		_ = store.file.Close()
+		store.file = nil
+		err = os.Rename(tmp.Name(), store.path)
+		if err != nil {
+			return errors.Join(fmt.Errorf("replacing log: %w", err), store.reopen())
+		}
+		return store.reopen()
+	})
+}
+
+// locked runs fn holding the in-process mutex and the cross-process file lock.
+func (store *Store) locked(exclusive bool, fn func() error) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	err := lockFile(store.lock, exclusive)
+	if err != nil {
+		return fmt.Errorf("locking %s: %w", store.lock.Name(), err)
+	}
+	defer func() { _ = unlockFile(store.lock) }()
+	return fn()
+}
+
+// reopen opens the log at store.path and rebuilds the index from scratch.
+func (store *Store) reopen() error {
+	file, err := os.OpenFile(store.path, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("opening log: %w", err)
+	}
+	if store.file != nil {
+		_ = store.file.Close()
+	}
+	store.file = file
+	store.offset = 0
+	store.index = map[string]entry{}
+	return store.scan()
+}
+
+// sync picks up changes made by other processes: a log replaced by Compact is
+// reopened, and records appended since the last call are indexed.
+func (store *Store) sync() error {
+	current, err := os.Stat(store.path)
+	if store.file == nil || errors.Is(err, os.ErrNotExist) {
+		return store.reopen()
+	}
+	if err != nil {
+		return fmt.Errorf("checking log: %w", err)
+	}
+	open, err := store.file.Stat()
+	if err != nil {
+		return fmt.Errorf("checking log: %w", err)
+	}
+	if !os.SameFile(current, open) {
+		return store.reopen()
+	}
+	if open.Size() == store.offset {
+		return nil
+	}
+	return store.scan()
+}
+
+// scan indexes the complete records after store.offset.
+func (store *Store) scan() error {
+	r := bufio.NewReader(io.NewSectionReader(store.file, store.offset, 1<<62))
+	for {
+		line, err := r.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			// an incomplete last line is ignored until its writer finishes or Put
+			// truncates it
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading log: %w", err)
+		}
+		var header struct {
+			Key       string    `json:"key"`
+			FetchedAt time.Time `json:"fetched_at"`
+		}
+		if json.Unmarshal(bytes.TrimSpace(line), &header) == nil && header.Key != "" {
+			store.index[header.Key] = entry{offset: store.offset, length: len(line), fetchedAt: header.FetchedAt}
+		}
+		store.offset += int64(len(line))
+	}
+}
+
+func (store *Store) read(e entry) (synthient.CacheRecord, error) {
+	line := make([]byte, e.length)
+	_, err := store.file.ReadAt(line, e.offset)
+	if err != nil {
+		return synthient.CacheRecord{}, fmt.Errorf("reading record: %w", err)
+	}
+	var record synthient.CacheRecord
+	err = json.Unmarshal(line, &record)
+	if err != nil {
+		return synthient.CacheRecord{}, fmt.Errorf("decoding record: %w", err)
+	}
+	return record, nil
+}
+
+func (store *Store) expired(fetchedAt time.Time) bool {
+	return store.options.MaxAge > 0 && time.Since(fetchedAt) > store.options.MaxAge
+}
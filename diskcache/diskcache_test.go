@@ -0,0 +1,136 @@
+package diskcache
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/synthient/go-synthient/v2"
+)
+
+func record(key string, value string, fetchedAt time.Time) synthient.CacheRecord {
+	return synthient.CacheRecord{Key: key, Value: json.RawMessage(value), FetchedAt: fetchedAt}
+}
+
+func TestStoreSharedAndCompacted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.log")
+	a, err := Open(path, Options{MaxAge: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	b, err := Open(path, Options{MaxAge: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	now := time.Now()
+	for _, r := range []synthient.CacheRecord{
+		record("ip:1.1.1.1", `{"ip":"1.1.1.1"}`, now),
+		record("ip:8.8.8.8", `{"ip":"8.8.8.8"}`, now.Add(-2*time.Hour)),
+		record("ip:1.1.1.1", `{"ip":"1.1.1.1","network":{"asn":13335}}`, now),
+	} {
+		err = a.Put(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// b sees a's appends, and the latest value wins
+	got, ok, err := b.Get("ip:1.1.1.1")
+	if err != nil || !ok || !strings.Contains(string(got.Value), "13335") {
+		t.Fatalf("Get = %s, %v, %v", got.Value, ok, err)
+	}
+	_, ok, _ = b.Get("ip:8.8.8.8")
+	if ok {
+		t.Error("expired record returned")
+	}
+
+	before, _ := os.Stat(path)
+	err = b.Compact()
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, _ := os.Stat(path)
+	if after.Size() >= before.Size() {
+		t.Errorf("compacted log is %d bytes, was %d", after.Size(), before.Size())
+	}
+
+	// a follows the replaced file
+	err = a.Put(record("domain:example.com", `{"domain":"example.com"}`, now))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", a.Len())
+	}
+	_, ok, err = b.Get("domain:example.com")
+	if err != nil || !ok {
+		t.Fatalf("Get after compaction = %v, %v", ok, err)
+	}
+}
+
+func TestStoreSurvivesRestart(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		_ = json.NewEncoder(w).Encode(synthient.IP{IP: strings.TrimPrefix(r.URL.Path, "/lookup/ip/")})
+	}))
+	defer server.Close()
+	base, _ := url.Parse(server.URL)
+	path := filepath.Join(t.TempDir(), "cache.log")
+
+	for range 2 {
+		store, err := Open(path, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		cache := synthient.NewLookupCache(synthient.CacheOptions{Store: store})
+		client := synthient.NewClient("key", synthient.WithBaseAPI(*base), synthient.WithCache(cache))
+		ip, err := client.GetIP("9.9.9.9", nil)
+		if err != nil || ip.IP != "9.9.9.9" {
+			t.Fatalf("GetIP = %+v, %v", ip, err)
+		}
+		_ = store.Close()
+	}
+	if calls.Load() != 1 {
+		t.Errorf("server called %d times, want 1", calls.Load())
+	}
+}
+
+func TestStoreIgnoresPartialRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.log")
+	err := os.WriteFile(path, []byte(`{"key":"ip:1.1.1.1","value":{},"fetched_at":"2026-01-01T00:00:00Z"}`+"\n"+`{"key":"ip:2.2`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := Open(path, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	err = store.Put(record("ip:3.3.3.3", `{}`, time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := Open(path, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	for _, key := range []string{"ip:1.1.1.1", "ip:3.3.3.3"} {
+		_, ok, err := reopened.Get(key)
+		if err != nil || !ok {
+			t.Errorf("Get(%s) = %v, %v", key, ok, err)
+		}
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !unix && !windows
+
+package diskcache
+
+import "os"
+
+// Without file locks a Store only synchronizes through its own mutex.
+
+func lockFile(file *os.File, exclusive bool) error { return nil }
+
+func unlockFile(file *os.File) error { return nil }
@@ -22,12 +22,8 @@ type Domain struct {
 		Events24H      int `json:"events_24h"`
 		TotalEvents30D int `json:"total_events_30d"`
 	} `json:"stats"`
-	TimeSeries []struct {
-		Date      int `json:"date"`
-		Events    int `json:"events"`
-		UniqueIPs int `json:"unique_ips"`
-	} `json:"time_series"`
-	UniqueIPs struct {
+	TimeSeries []DomainTimePoint `json:"time_series"`
+	UniqueIPs  struct {
 		Value24H     int   `json:"value_24h"`
 		Value30D     int   `json:"value_30d"`
 		Sparkline24H []int `json:"sparkline_24h"`
@@ -57,13 +53,23 @@ type Domain struct {
 		P95PerHour    int    `json:"p95_per_hour"`
 		Cadence       string `json:"cadence"`
 	} `json:"activity_stats"`
-	RecentEvents []struct {
-		Timestamp       int    `json:"timestamp"`
-		SourceIPMasked  string `json:"source_ip_masked"`
-		TargetSubdomain string `json:"target_subdomain"`
-		Port            int    `json:"port"`
-		CountryCode     string `json:"country_code"`
-	} `json:"recent_events"`
+	RecentEvents []DomainEvent `json:"recent_events"`
+}
+
+// DomainTimePoint is one hour of Domain.TimeSeries. Time returns Date as a time.Time.
+type DomainTimePoint struct {
+	Date      int `json:"date"`
+	Events    int `json:"events"`
+	UniqueIPs int `json:"unique_ips"`
+}
+
+// DomainEvent is one of Domain.RecentEvents. Time returns Timestamp as a time.Time.
+type DomainEvent struct {
+	Timestamp       int    `json:"timestamp"`
+	SourceIPMasked  string `json:"source_ip_masked"`
+	TargetSubdomain string `json:"target_subdomain"`
+	Port            int    `json:"port"`
+	CountryCode     string `json:"country_code"`
 }
 
 // GetDomain retrieves traffic statistics and recent activity for a single domain.
@@ -81,7 +87,22 @@ type Domain struct {
 //		log.Fatal(err)
 //	}
 //	fmt.Printf("%+v\n", domain)
+//
+// When client.Cache is set, a fresh cached result is returned without a request.
+// Concurrent calls for the same domain share one request (see WithoutCoalescing).
 func (client *Client) GetDomain(domain string, options *RequestOptions) (Domain, error) {
+	lookup := func() (Domain, error) {
+		return coalesce(client, domainCacheKey(domain), options, func(options *RequestOptions) (Domain, error) {
+			return client.getDomain(domain, options)
+		})
+	}
+	if client.Cache == nil {
+		return lookup()
+	}
+	return cachedLookup(client.Cache, domainCacheKey(domain), client.Cache.options.DomainTTL, lookup)
+}
+
+func (client *Client) getDomain(domain string, options *RequestOptions) (Domain, error) {
 	path, err := url.JoinPath(client.BaseAPI.String(), "lookup", "domain", domain)
 	if err != nil {
 		return Domain{}, fmt.Errorf("creating path for domain request: %w", err)
@@ -92,7 +113,9 @@ func (client *Client) GetDomain(domain string, options *RequestOptions) (Domain,
 		return Domain{}, fmt.Errorf("making request for domain (%s): %w", domain, err)
 	}
 
-	resp, err := requestJSON[Domain](options, client, req, http.StatusOK)
+	resp, err := withQuota(client, options, 1, func() (Domain, error) {
+		return requestJSON[Domain](options, client, &Call{Operation: "GetDomain", Subject: domain}, req, http.StatusOK)
+	})
 	if err != nil {
 		return Domain{}, fmt.Errorf("requesting JSON data: %w", err)
 	}
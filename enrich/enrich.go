@@ -0,0 +1,452 @@
+// Package enrich joins Synthient IP intelligence into access logs.
+//
+// Run reads a Common Log Format, Combined Log Format or JSON-lines log, looks up every
+// distinct client IP with GetIPs in batches, and writes each line back out as JSONL or
+// CSV with the selected IP fields flattened next to it. The cmd/synthient-enrich
+// command wraps it for the shell.
+//
+// Lookups are the slow and costly part, so they can be checkpointed: with
+// Options.State set, every batch is saved as it completes and a rerun after an
+// interruption only looks up the addresses that are still missing. The state is a
+// synthient.CacheStore, normally a diskcache.Store, keyed like LookupCache so the
+// same file can also back a cache.
+//
+// Example:
+//
+//	in, err := os.Open("access.log")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer in.Close()
+//	stats, err := enrich.Run(ctx, &client, in, os.Stdout, &enrich.Options{Format: enrich.FormatCombined})
+package enrich
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/synthient/go-synthient/v2"
+	"github.com/synthient/go-synthient/v2/export"
+)
+
+// DefaultFields are the IP fields added when Options.Fields is empty.
+var DefaultFields = []string{
+	"intelligence_risk_score", "location_country", "network_asn", "network_isp", "network_type",
+	"intelligence_categories",
+}
+
+// Fields returns the names accepted in Options.Fields: the columns of
+// export.IPColumns.
+func Fields() []string {
+	var names []string
+	for _, column := range export.IPColumns() {
+		names = append(names, column.Name)
+	}
+	return names
+}
+
+// DefaultBatchSize is the number of IPs sent per GetIPs request unless
+// Options.BatchSize says otherwise.
+const DefaultBatchSize = 100
+
+// DefaultIPField is the JSON field holding the client IP unless Options.IPField says
+// otherwise.
+const DefaultIPField = "remote_addr"
+
+// DefaultPrefix is prepended to the names of added fields unless Options.Prefix says
+// otherwise.
+const DefaultPrefix = "synthient_"
+
+// MaxLineSize is the longest log line Run accepts.
+const MaxLineSize = 16 << 20
+
+// Output is an output format.
+type Output string
+
+// Supported output formats.
+const (
+	// OutputJSONL writes one JSON object per line. Common and Combined log lines
+	// become objects of their parsed fields; JSON lines keep every original field.
+	OutputJSONL Output = "jsonl"
+	// OutputCSV writes a header row and one row per line. Common and Combined log
+	// lines are split into their parsed fields; JSON lines are kept whole in a
+	// "line" column.
+	OutputCSV Output = "csv"
+)
+
+// Options configures Run. The zero value detects the log format, reads JSON client
+// IPs from "remote_addr" and writes JSONL with DefaultFields.
+type Options struct {
+	// Format is the log format. Defaults to FormatAuto.
+	Format Format
+	// IPField is the field holding the client IP in JSON logs, as a dotted path for
+	// nested objects (e.g. "client.ip"). Defaults to DefaultIPField.
+	IPField string
+	// Output is the output format. Defaults to OutputJSONL.
+	Output Output
+	// Fields are the IP fields added to each line, named and flattened as by the
+	// export package. Repeated fields are joined with ";" in CSV and kept as arrays
+	// in JSONL. Defaults to DefaultFields.
+	Fields []string
+	// Prefix is prepended to the added field names. Defaults to DefaultPrefix.
+	Prefix string
+	// BatchSize is the number of IPs sent per GetIPs request. Defaults to
+	// DefaultBatchSize.
+	BatchSize int
+	// State, if set, checkpoints lookups so an interrupted run can resume without
+	// repeating them.
+	State synthient.CacheStore
+	// Progress, if set, is called after each batch of lookups with the number of
+	// addresses resolved so far and the total.
+	Progress func(done, total int)
+}
+
+// Stats reports what Run did.
+type Stats struct {
+	// Lines is the number of lines read, excluding blank lines.
+	Lines int
+	// Unparsed is the number of lines that did not match the log format. They are
+	// written without enrichment.
+	Unparsed int
+	// Enriched is the number of lines written with IP fields.
+	Enriched int
+	// UniqueIPs is the number of distinct client addresses that could be looked up.
+	UniqueIPs int
+	// LookedUp is the number of addresses looked up by this run.
+	LookedUp int
+	// Resumed is the number of addresses taken from Options.State.
+	Resumed int
+	// Missing is the number of addresses the lookups returned no result for. Lines
+	// with them are written without enrichment.
+	Missing int
+}
+
+// Run enriches the log read from in and writes the result to out, as described in the
+// package documentation. in is read twice, once to collect addresses and once to
+// write the output, so it must be seekable.
+//
+// When a lookup fails, Run returns the error before writing any output; batches
+// completed so far are kept in Options.State for the next run.
+func Run(ctx context.Context, lookups synthient.IPLookuper, in io.ReadSeeker, out io.Writer, options *Options) (Stats, error) {
+	opts, flattener, err := withDefaults(options)
+	if err != nil {
+		return Stats{}, err
+	}
+	var stats Stats
+
+	ips := map[string]bool{}
+	err = scan(in, func(line []byte) {
+		if opts.Format == FormatAuto {
+			opts.Format = detect(line)
+		}
+		stats.Lines++
+		e := parse(opts.Format, opts.IPField, line)
+		if !e.parsed {
+			stats.Unparsed++
+		}
+		if e.ip != "" {
+			ips[e.ip] = true
+		}
+	})
+	if err != nil {
+		return stats, err
+	}
+	stats.UniqueIPs = len(ips)
+
+	results, err := resolve(ctx, lookups, slices.Sorted(maps.Keys(ips)), &opts, &stats)
+	if err != nil {
+		return stats, err
+	}
+
+	_, err = in.Seek(0, io.SeekStart)
+	if err != nil {
+		return stats, fmt.Errorf("rewinding log: %w", err)
+	}
+	w := newWriter(out, &opts, flattener)
+	err = w.header()
+	if err != nil {
+		return stats, err
+	}
+	var writeErr error
+	err = scan(in, func(line []byte) {
+		if writeErr != nil {
+			return
+		}
+		e := parse(opts.Format, opts.IPField, line)
+		ip, ok := results[e.ip]
+		if ok {
+			stats.Enriched++
+		}
+		writeErr = w.write(e, ip, ok)
+	})
+	err = cmp.Or(err, writeErr)
+	if err != nil {
+		return stats, err
+	}
+	return stats, w.flush()
+}
+
+func withDefaults(options *Options) (Options, *export.Flattener[synthient.IP], error) {
+	var opts Options
+	if options != nil {
+		opts = *options
+	}
+	err := validFormat(opts.Format)
+	if err != nil {
+		return opts, nil, err
+	}
+	opts.Output = cmp.Or(opts.Output, OutputJSONL)
+	if opts.Output != OutputJSONL && opts.Output != OutputCSV {
+		return opts, nil, fmt.Errorf("unknown output format %q", opts.Output)
+	}
+	opts.IPField = cmp.Or(opts.IPField, DefaultIPField)
+	opts.Prefix = cmp.Or(opts.Prefix, DefaultPrefix)
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+	if len(opts.Fields) == 0 {
+		opts.Fields = DefaultFields
+	}
+	flattener, err := export.NewIPFlattener(opts.Fields, "")
+	if err != nil {
+		return opts, nil, err
+	}
+	return opts, flattener, nil
+}
+
+// scan calls fn with every non-blank line of r.
+func scan(r io.Reader, fn func(line []byte)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), MaxLineSize)
+	for scanner.Scan() {
+		line := bytes.TrimRight(scanner.Bytes(), "\r")
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		fn(line)
+	}
+	err := scanner.Err()
+	if err != nil {
+		return fmt.Errorf("reading log: %w", err)
+	}
+	return nil
+}
+
+// stateKey matches the keys LookupCache uses, so a state file can back a cache.
+func stateKey(ip string) string { return "ip:" + ip }
+
+// resultKey returns the canonical address of a lookup result, matching the form
+// canonical gives the addresses read from the log, or ip.IP when it does not parse.
+// Results are matched by address since the order of a GetIPs response is not
+// guaranteed.
+func resultKey(ip synthient.IP) string {
+	if ip.Addr.IsValid() {
+		return ip.Addr.Unmap().String()
+	}
+	addr, err := synthient.ParseAddr(ip.IP)
+	if err != nil {
+		return ip.IP
+	}
+	return addr.String()
+}
+
+// resolve looks up ips, taking what it can from opts.State and saving each completed
+// batch to it.
+func resolve(ctx context.Context, lookups synthient.IPLookuper, ips []string, opts *Options, stats *Stats) (map[string]synthient.IP, error) {
+	results := make(map[string]synthient.IP, len(ips))
+	var missing []string
+	for _, ip := range ips {
+		if opts.State != nil {
+			record, ok, err := opts.State.Get(stateKey(ip))
+			if err != nil {
+				return nil, fmt.Errorf("reading state: %w", err)
+			}
+			var result synthient.IP
+			if ok && json.Unmarshal(record.Value, &result) == nil {
+				results[ip] = result
+				stats.Resumed++
+				continue
+			}
+		}
+		missing = append(missing, ip)
+	}
+	if opts.Progress != nil {
+		opts.Progress(len(results), len(ips))
+	}
+
+	for batch := range slices.Chunk(missing, opts.BatchSize) {
+		err := ctx.Err()
+		if err != nil {
+			return nil, err
+		}
+		found, err := lookups.GetIPs(batch, &synthient.RequestOptions{Context: ctx})
+		if err != nil {
+			return nil, fmt.Errorf("looking up %d ips: %w", len(batch), err)
+		}
+		byAddr := make(map[string]synthient.IP, len(found))
+		for _, result := range found {
+			byAddr[resultKey(result)] = result
+		}
+		now := time.Now()
+		for _, ip := range batch {
+			result, ok := byAddr[ip]
+			if !ok {
+				stats.Missing++
+				continue
+			}
+			results[ip] = result
+			stats.LookedUp++
+			if opts.State == nil {
+				continue
+			}
+			value, err := json.Marshal(result)
+			if err == nil {
+				err = opts.State.Put(synthient.CacheRecord{Key: stateKey(ip), Value: value, FetchedAt: now})
+			}
+			if err != nil {
+				return nil, fmt.Errorf("saving state: %w", err)
+			}
+		}
+		if opts.Progress != nil {
+			opts.Progress(len(results), len(ips))
+		}
+	}
+	return results, nil
+}
+
+// writer writes enriched lines in the chosen output format.
+type writer struct {
+	opts      *Options
+	flattener *export.Flattener[synthient.IP]
+	out       *bufio.Writer
+	csv       *csv.Writer
+}
+
+func newWriter(out io.Writer, opts *Options, flattener *export.Flattener[synthient.IP]) *writer {
+	w := &writer{opts: opts, flattener: flattener, out: bufio.NewWriter(out)}
+	if opts.Output == OutputCSV {
+		w.csv = csv.NewWriter(w.out)
+	}
+	return w
+}
+
+func (w *writer) header() error {
+	if w.csv == nil {
+		return nil
+	}
+	columns := slices.Clone(logFields(w.opts.Format))
+	if columns == nil {
+		columns = []string{"line"}
+	}
+	for _, name := range w.flattener.Columns() {
+		columns = append(columns, w.opts.Prefix+name)
+	}
+	return w.csv.Write(columns)
+}
+
+func (w *writer) write(e entry, ip synthient.IP, enriched bool) error {
+	if w.csv != nil {
+		return w.writeCSV(e, ip, enriched)
+	}
+	return w.writeJSON(e, ip, enriched)
+}
+
+func (w *writer) writeCSV(e entry, ip synthient.IP, enriched bool) error {
+	record := make([]string, 0, len(logFields(w.opts.Format))+len(w.opts.Fields))
+	switch {
+	case logFields(w.opts.Format) == nil:
+		record = append(record, string(e.raw))
+	case e.parsed:
+		record = append(record, e.fields...)
+	default:
+		// Keep unparsed lines in the first column rather than dropping them.
+		record = append(record, string(e.raw))
+		for len(record) < len(logFields(w.opts.Format)) {
+			record = append(record, "")
+		}
+	}
+	if !enriched {
+		return w.csv.Write(append(record, make([]string, len(w.opts.Fields))...))
+	}
+	for _, value := range w.flattener.Rows(ip)[0] {
+		record = append(record, export.FormatValue(value, export.DefaultDelimiter))
+	}
+	return w.csv.Write(record)
+}
+
+func (w *writer) writeJSON(e entry, ip synthient.IP, enriched bool) error {
+	var object bytes.Buffer
+	switch {
+	case w.opts.Format == FormatJSON && e.parsed:
+		// Append to the original object so its fields and their order survive.
+		original := bytes.TrimSpace(e.raw)
+		object.Write(bytes.TrimSpace(original[:len(original)-1]))
+		if !bytes.HasSuffix(bytes.TrimSpace(object.Bytes()), []byte("{")) && enriched {
+			object.WriteByte(',')
+		}
+	case e.parsed:
+		object.WriteByte('{')
+		for i, name := range logFields(w.opts.Format) {
+			if i > 0 {
+				object.WriteByte(',')
+			}
+			writeMember(&object, name, e.fields[i])
+		}
+		if enriched {
+			object.WriteByte(',')
+		}
+	default:
+		object.WriteByte('{')
+		writeMember(&object, "line", string(e.raw))
+		if enriched {
+			object.WriteByte(',')
+		}
+	}
+	if enriched {
+		row := w.flattener.Rows(ip)[0]
+		for i, name := range w.flattener.Columns() {
+			if i > 0 {
+				object.WriteByte(',')
+			}
+			writeMember(&object, w.opts.Prefix+name, row[i])
+		}
+	}
+	object.WriteString("}\n")
+	_, err := w.out.Write(object.Bytes())
+	return err
+}
+
+func writeMember(object *bytes.Buffer, name string, value any) {
+	key, _ := json.Marshal(name)
+	data, err := json.Marshal(value)
+	if err != nil {
+		data = []byte("null")
+	}
+	object.Write(key)
+	object.WriteByte(':')
+	object.Write(data)
+}
+
+func (w *writer) flush() error {
+	if w.csv != nil {
+		w.csv.Flush()
+		err := w.csv.Error()
+		if err != nil {
+			return fmt.Errorf("writing output: %w", err)
+		}
+	}
+	err := w.out.Flush()
+	if err != nil {
+		return fmt.Errorf("writing output: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package enrich
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/synthient/go-synthient/v2"
+	"github.com/synthient/go-synthient/v2/diskcache"
+	"github.com/synthient/go-synthient/v2/synthienttest"
+)
+
+const combinedLog = `213.149.183.127 - - [10/Mar/2026:13:55:36 +0000] "GET /login HTTP/1.1" 200 2326 "https://example.com/" "Mozilla/5.0"
+213.149.183.77 - frank [10/Mar/2026:13:55:37 +0000] "POST /login HTTP/1.1" 401 12 "-" "curl/8.5.0"
+
+garbage line
+10.0.0.5 - - [10/Mar/2026:13:55:38 +0000] "GET /health HTTP/1.1" 200 2 "-" "probe"
+213.149.183.127 - - [10/Mar/2026:13:55:39 +0000] "GET /a \"quoted\" HTTP/1.1" 200 5 "-" "Mozilla/5.0"
+`
+
+func newFake() *synthienttest.Fake {
+	fake := synthienttest.NewFake()
+	var risky synthient.IP
+	risky.IP = "213.149.183.77"
+	risky.Network.Asn = 64500
+	risky.Intelligence.RiskScore = 95
+	risky.Intelligence.Categories = []string{"VPN", "HOSTING"}
+	fake.AddIP(risky)
+	var clean synthient.IP
+	clean.IP = "213.149.183.127"
+	clean.Location.Country = "DE"
+	clean.Intelligence.RiskScore = 5
+	fake.AddIP(clean)
+	return fake
+}
+
+func TestRunCombinedToJSONL(t *testing.T) {
+	var out bytes.Buffer
+	stats, err := Run(context.Background(), newFake(), strings.NewReader(combinedLog), &out, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Stats{Lines: 5, Unparsed: 1, Enriched: 3, UniqueIPs: 2, LookedUp: 2}
+	if stats != want {
+		t.Errorf("stats = %+v, want %+v", stats, want)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("wrote %d lines, want 5:\n%s", len(lines), out.String())
+	}
+	var first map[string]any
+	err = json.Unmarshal([]byte(lines[1]), &first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first["user"] != "frank" || first["user_agent"] != "curl/8.5.0" || first["synthient_intelligence_risk_score"] != 95.0 {
+		t.Errorf("line 2 = %s", lines[1])
+	}
+	if categories, _ := first["synthient_intelligence_categories"].([]any); len(categories) != 2 {
+		t.Errorf("categories = %v, want a list of 2", first["synthient_intelligence_categories"])
+	}
+	if lines[2] != `{"line":"garbage line"}` {
+		t.Errorf("unparsed line = %s", lines[2])
+	}
+	if strings.Contains(lines[3], "synthient_") {
+		t.Errorf("private address was enriched: %s", lines[3])
+	}
+}
+
+func TestRunJSONToCSV(t *testing.T) {
+	log := `{"ts":"2026-03-10T13:55:36Z","client":{"ip":"213.149.183.77:443"},"path":"/"}
+{"ts":"2026-03-10T13:55:37Z","client":{"ip":"213.149.183.127"}}
+`
+	var out bytes.Buffer
+	_, err := Run(context.Background(), newFake(), strings.NewReader(log), &out, &Options{
+		IPField: "client.ip",
+		Output:  OutputCSV,
+		Fields:  []string{"ip", "intelligence_risk_score", "intelligence_categories", "is_vpn"},
+		Prefix:  "x_",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"line", "x_ip", "x_intelligence_risk_score", "x_intelligence_categories", "x_is_vpn"},
+		{`{"ts":"2026-03-10T13:55:36Z","client":{"ip":"213.149.183.77:443"},"path":"/"}`, "213.149.183.77", "95", "VPN;HOSTING", "true"},
+		{`{"ts":"2026-03-10T13:55:37Z","client":{"ip":"213.149.183.127"}}`, "213.149.183.127", "5", "", "false"},
+	}
+	for i := range want {
+		if i >= len(records) || !slices.Equal(records[i], want[i]) {
+			t.Errorf("row %d = %q, want %q", i, records[min(i, len(records)-1)], want[i])
+		}
+	}
+}
+
+func TestRunJSONLKeepsOriginalFields(t *testing.T) {
+	var out bytes.Buffer
+	_, err := Run(context.Background(), newFake(), strings.NewReader(`{"remote_addr":"213.149.183.127","b":1,"a":2}`+"\n{}\n"), &out, &Options{Fields: []string{"location_country"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"remote_addr":"213.149.183.127","b":1,"a":2,"synthient_location_country":"DE"}` + "\n{}\n"
+	if out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+}
+
+// flakyLookuper fails every GetIPs call after the first allowed ones.
+type flakyLookuper struct {
+	synthient.IPLookuper
+	allowed int
+	batches [][]string
+}
+
+func (lookups *flakyLookuper) GetIPs(ips []string, options *synthient.RequestOptions) ([]synthient.IP, error) {
+	if len(lookups.batches) >= lookups.allowed {
+		return nil, synthient.ErrServiceUnavailable
+	}
+	lookups.batches = append(lookups.batches, ips)
+	return lookups.IPLookuper.GetIPs(ips, options)
+}
+
+func TestRunResumes(t *testing.T) {
+	state, err := diskcache.Open(filepath.Join(t.TempDir(), "state"), diskcache.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer state.Close()
+	options := &Options{BatchSize: 1, State: state}
+
+	first := &flakyLookuper{IPLookuper: newFake(), allowed: 1}
+	var out bytes.Buffer
+	_, err = Run(context.Background(), first, strings.NewReader(combinedLog), &out, options)
+	if !errors.Is(err, synthient.ErrServiceUnavailable) || out.Len() != 0 {
+		t.Fatalf("interrupted run err = %v, wrote %d bytes", err, out.Len())
+	}
+
+	second := &flakyLookuper{IPLookuper: newFake(), allowed: 10}
+	stats, err := Run(context.Background(), second, strings.NewReader(combinedLog), &out, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Resumed != 1 || stats.LookedUp != 1 || stats.Enriched != 3 {
+		t.Errorf("resumed run stats = %+v", stats)
+	}
+	if len(second.batches) != 1 || slices.Equal(second.batches[0], first.batches[0]) {
+		t.Errorf("resumed run looked up %v after %v", second.batches, first.batches)
+	}
+}
+
+// reversedLookuper returns GetIPs results in reverse order, dropping 213.149.183.127.
+type reversedLookuper struct {
+	synthient.IPLookuper
+}
+
+func (lookups reversedLookuper) GetIPs(ips []string, options *synthient.RequestOptions) ([]synthient.IP, error) {
+	found, err := lookups.IPLookuper.GetIPs(ips, options)
+	slices.Reverse(found)
+	found = slices.DeleteFunc(found, func(ip synthient.IP) bool { return ip.IP == "213.149.183.127" })
+	return found, err
+}
+
+func TestRunMatchesResultsByAddress(t *testing.T) {
+	var risky synthient.IP
+	risky.IP = "213.149.183.78"
+	risky.Intelligence.RiskScore = 80
+	fake := newFake()
+	fake.AddIP(risky)
+	log := combinedLog + strings.Replace(strings.SplitAfter(combinedLog, "\n")[1], "213.149.183.77", "213.149.183.78", 1)
+
+	var out bytes.Buffer
+	stats, err := Run(context.Background(), reversedLookuper{fake}, strings.NewReader(log), &out, &Options{
+		Fields: []string{"intelligence_risk_score"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.LookedUp != 2 || stats.Missing != 1 || stats.Enriched != 2 {
+		t.Errorf("stats = %+v", stats)
+	}
+	scores := map[string]any{}
+	for line := range strings.Lines(out.String()) {
+		var record map[string]any
+		err := json.Unmarshal([]byte(line), &record)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if score, ok := record["synthient_intelligence_risk_score"]; ok {
+			scores[fmt.Sprint(record["remote_host"])] = score
+		}
+	}
+	want := map[string]any{"213.149.183.77": float64(95), "213.149.183.78": float64(80)}
+	if !maps.Equal(scores, want) {
+		t.Errorf("scores = %v, want %v", scores, want)
+	}
+}
+
+func TestRunRejectsUnknownField(t *testing.T) {
+	_, err := Run(context.Background(), newFake(), strings.NewReader(""), &bytes.Buffer{}, &Options{Fields: []string{"nope"}})
+	if err == nil {
+		t.Fatal("unknown field accepted")
+	}
+}
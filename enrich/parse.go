@@ -0,0 +1,146 @@
+package enrich
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/synthient/go-synthient/v2"
+	"github.com/synthient/go-synthient/v2/internal/clientip"
+)
+
+// Format is an access log format.
+type Format string
+
+// Supported log formats.
+const (
+	// FormatAuto detects the format from the first non-empty line.
+	FormatAuto Format = ""
+	// FormatCommon is the Common Log Format (Apache "common", nginx without the
+	// trailing referer and user agent).
+	FormatCommon Format = "common"
+	// FormatCombined is the Combined Log Format (Apache "combined", nginx default).
+	FormatCombined Format = "combined"
+	// FormatJSON is one JSON object per line, with the client IP in Options.IPField.
+	FormatJSON Format = "json"
+)
+
+const quoted = `"((?:[^"\\]|\\.)*)"`
+
+var (
+	commonPattern   = regexp.MustCompile(`^(\S+) (\S+) (\S+) \[([^\]]+)\] ` + quoted + ` (\d{3}) (\S+)`)
+	combinedPattern = regexp.MustCompile(commonPattern.String() + ` ` + quoted + ` ` + quoted)
+)
+
+// commonFields and combinedFields name the submatches of the patterns above.
+var (
+	commonFields   = []string{"remote_host", "ident", "user", "time", "request", "status", "bytes"}
+	combinedFields = append(commonFields[:len(commonFields):len(commonFields)], "referer", "user_agent")
+)
+
+// detect returns the format of line.
+func detect(line []byte) Format {
+	switch {
+	case bytes.HasPrefix(bytes.TrimSpace(line), []byte("{")):
+		return FormatJSON
+	case combinedPattern.Match(line):
+		return FormatCombined
+	default:
+		return FormatCommon
+	}
+}
+
+// entry is a parsed log line.
+type entry struct {
+	// raw is the line as read, without its line ending.
+	raw []byte
+	// fields are the values of logFields, for the Common and Combined formats.
+	fields []string
+	// ip is the canonical client address, or empty when the line has none that can
+	// be looked up.
+	ip string
+	// parsed reports whether the line matched its format.
+	parsed bool
+}
+
+// logFields returns the names of the fields format parses out of a line.
+func logFields(format Format) []string {
+	switch format {
+	case FormatCommon:
+		return commonFields
+	case FormatCombined:
+		return combinedFields
+	default:
+		return nil
+	}
+}
+
+// parse parses one line. Lines that do not match the format are returned unparsed
+// rather than as errors, since real logs contain the odd truncated line.
+func parse(format Format, ipField string, line []byte) entry {
+	e := entry{raw: line}
+	switch format {
+	case FormatCommon, FormatCombined:
+		pattern := commonPattern
+		if format == FormatCombined {
+			pattern = combinedPattern
+		}
+		match := pattern.FindSubmatch(line)
+		if match == nil {
+			return e
+		}
+		e.parsed = true
+		for _, value := range match[1:] {
+			e.fields = append(e.fields, string(value))
+		}
+		e.ip = canonical(e.fields[0])
+	case FormatJSON:
+		var object map[string]any
+		if json.Unmarshal(line, &object) != nil {
+			return e
+		}
+		e.parsed = true
+		if value, ok := lookupPath(object, ipField).(string); ok {
+			e.ip = canonical(value)
+		}
+	}
+	return e
+}
+
+// lookupPath returns the value at a dotted path such as "client.ip" in object. A key
+// containing dots is matched before descending.
+func lookupPath(object map[string]any, path string) any {
+	if value, ok := object[path]; ok {
+		return value
+	}
+	head, rest, found := strings.Cut(path, ".")
+	if !found {
+		return nil
+	}
+	child, ok := object[head].(map[string]any)
+	if !ok {
+		return nil
+	}
+	return lookupPath(child, rest)
+}
+
+// canonical returns the canonical form of a logged client address, which may carry a
+// port, or "" when it cannot be looked up.
+func canonical(host string) string {
+	addr, ok := clientip.ParseHost(host)
+	if !ok || synthient.ValidateAddr(addr) != nil {
+		return ""
+	}
+	return addr.String()
+}
+
+func validFormat(format Format) error {
+	switch format {
+	case FormatAuto, FormatCommon, FormatCombined, FormatJSON:
+		return nil
+	default:
+		return fmt.Errorf("unknown log format %q", format)
+	}
+}
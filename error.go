@@ -1,16 +1,155 @@
 package synthient
 
-import "errors"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
 
 var (
 	ErrNoToken    = errors.New("no token provided for client")
 	ErrFileExists = errors.New("file already exists")
+	// ErrQuotaExhausted is returned by lookups that a Client.Limiter rejected locally
+	// because the remaining credits would not cover them.
+	ErrQuotaExhausted = errors.New("lookup quota exhausted")
 )
 
 var (
 	ErrBadRequest           = errors.New("invalid input parameters")
 	ErrUnauthorized         = errors.New("no api key was provided or the key is invalid")
 	ErrPaymentRequired      = errors.New("credits have run out")
+	ErrForbidden            = errors.New("api key does not have access to this resource")
+	ErrNotFound             = errors.New("requested resource was not found")
+	ErrTooManyRequests      = errors.New("too many requests")
 	ErrInternalServerError  = errors.New("unexpected error occurred")
+	ErrServiceUnavailable   = errors.New("service is temporarily unavailable")
 	ErrUnexpectedStatusCode = errors.New("returned status code did not match expected status code")
 )
+
+// maxErrorBody bounds how much of an error response body is kept on an APIError.
+const maxErrorBody = 64 << 10
+
+// APIError is returned when the Synthient API answers with a status code other than the
+// one a call expected. It keeps the response details needed for debugging and support
+// requests.
+//
+// APIError unwraps to the sentinel matching its status code (ErrBadRequest,
+// ErrUnauthorized, ErrPaymentRequired, ErrForbidden, ErrNotFound, ErrTooManyRequests,
+// ErrInternalServerError or ErrServiceUnavailable), or to ErrUnexpectedStatusCode for
+// any other status, so errors.Is keeps working on wrapped errors:
+//
+//	_, err := client.GetIP("8.8.8.8", nil)
+//	var apiErr *synthient.APIError
+//	if errors.As(err, &apiErr) {
+//		log.Printf("status=%d request_id=%s: %s", apiErr.StatusCode, apiErr.RequestID, apiErr.Message)
+//	}
+//	if errors.Is(err, synthient.ErrPaymentRequired) {
+//		// out of credits
+//	}
+type APIError struct {
+	// StatusCode is the HTTP status returned by the server.
+	StatusCode int
+	// ExpectedStatusCode is the status the call was waiting for.
+	ExpectedStatusCode int
+	// Method and URL identify the request that failed. URL is redacted as in the
+	// client's logs: the query string, which holds presigned credentials on snapshot
+	// redirects, is dropped and the looked-up subject is masked when
+	// Client.MaskSubjects is set.
+	Method string
+	URL    string
+	// Header holds the response headers.
+	Header http.Header
+	// RequestID is the server-assigned request identifier (X-Request-Id), if any.
+	RequestID string
+	// Message is the "error" field decoded from the JSON response body, if any.
+	Message string
+	// Body is the raw response body, truncated to 64 KiB.
+	Body []byte
+
+	sentinel error
+}
+
+func (e *APIError) Error() string {
+	sentinel := e.Unwrap()
+	msg := fmt.Sprintf("%s (%d %s)", sentinel, e.StatusCode, http.StatusText(e.StatusCode))
+	if sentinel == ErrUnexpectedStatusCode {
+		msg = fmt.Sprintf(
+			`%s (%d "%s", %d "%s" expected)`,
+			sentinel,
+			e.StatusCode,
+			http.StatusText(e.StatusCode),
+			e.ExpectedStatusCode,
+			http.StatusText(e.ExpectedStatusCode),
+		)
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+// Unwrap returns the sentinel for the status code. APIErrors built outside the package
+// have none recorded, so it is derived from StatusCode.
+func (e *APIError) Unwrap() error {
+	if e.sentinel == nil {
+		return statusSentinel(e.StatusCode)
+	}
+	return e.sentinel
+}
+
+// statusSentinel maps an HTTP status code to the package error it represents.
+func statusSentinel(statusCode int) error {
+	switch statusCode {
+	case http.StatusBadRequest:
+		return ErrBadRequest
+	case http.StatusUnauthorized:
+		return ErrUnauthorized
+	case http.StatusPaymentRequired:
+		return ErrPaymentRequired
+	case http.StatusForbidden:
+		return ErrForbidden
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusTooManyRequests:
+		return ErrTooManyRequests
+	case http.StatusInternalServerError:
+		return ErrInternalServerError
+	case http.StatusServiceUnavailable:
+		return ErrServiceUnavailable
+	default:
+		return ErrUnexpectedStatusCode
+	}
+}
+
+// newAPIError builds an APIError from response, consuming (but not closing) its body.
+// requestURL is the redacted URL of the request that failed.
+func newAPIError(response *http.Response, expectedStatusCode int, requestURL string) *APIError {
+	apiErr := &APIError{
+		StatusCode:         response.StatusCode,
+		ExpectedStatusCode: expectedStatusCode,
+		Header:             response.Header,
+		URL:                requestURL,
+		RequestID:          response.Header.Get("X-Request-Id"),
+		sentinel:           statusSentinel(response.StatusCode),
+	}
+	if response.Request != nil {
+		apiErr.Method = response.Request.Method
+	}
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, maxErrorBody))
+	if err != nil {
+		return apiErr
+	}
+	apiErr.Body = body
+	var e struct {
+		Error string `json:"error"`
+	}
+	err = json.Unmarshal(body, &e)
+	if err == nil {
+		apiErr.Message = strings.TrimSpace(e.Error)
+	}
+	return apiErr
+}
@@ -0,0 +1,58 @@
+package synthient
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestAPIError(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "req_123")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"invalid ip address"}`))
+	}))
+
+	_, err := client.GetIP("8.8.8.8", nil)
+	if !errors.Is(err, ErrBadRequest) {
+		t.Fatalf("err = %v, want ErrBadRequest", err)
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("err = %T, want *APIError in chain", err)
+	}
+	if apiErr.StatusCode != http.StatusBadRequest || apiErr.Method != http.MethodGet {
+		t.Errorf("status/method = %d %s", apiErr.StatusCode, apiErr.Method)
+	}
+	if apiErr.RequestID != "req_123" || apiErr.Message != "invalid ip address" {
+		t.Errorf("request id/message = %q %q", apiErr.RequestID, apiErr.Message)
+	}
+}
+
+func TestStatusSentinel(t *testing.T) {
+	cases := map[int]error{
+		http.StatusForbidden:          ErrForbidden,
+		http.StatusNotFound:           ErrNotFound,
+		http.StatusTooManyRequests:    ErrTooManyRequests,
+		http.StatusServiceUnavailable: ErrServiceUnavailable,
+		http.StatusTeapot:             ErrUnexpectedStatusCode,
+	}
+	for code, want := range cases {
+		err := error(&APIError{StatusCode: code, sentinel: statusSentinel(code)})
+		if !errors.Is(err, want) {
+			t.Errorf("status %d does not unwrap to %v", code, want)
+		}
+	}
+}
+
+func TestAPIErrorWithoutSentinel(t *testing.T) {
+	err := &APIError{StatusCode: http.StatusPaymentRequired}
+	if !errors.Is(err, ErrPaymentRequired) {
+		t.Errorf("err = %v, want ErrPaymentRequired", err)
+	}
+	zero := &APIError{}
+	if !errors.Is(zero, ErrUnexpectedStatusCode) || strings.Contains(zero.Error(), "%!") {
+		t.Errorf("zero APIError = %q", zero.Error())
+	}
+}
@@ -0,0 +1,203 @@
+package export
+
+import (
+	"time"
+
+	"github.com/synthient/go-synthient/v2"
+)
+
+// Column describes one column of flattened output.
+type Column struct {
+	// Name is the column name, the JSON path of the field joined with '_'.
+	Name string
+	// Group is the repeated field the column comes from, such as "providers", or
+	// empty for a column with one value per record. Options.Explode takes a group.
+	Group string
+	// Description says what the column holds.
+	Description string
+}
+
+// column is a Column with the accessor for its values. Scalar columns have value;
+// repeated columns have element, which returns the field of element i of their group.
+type column[T any] struct {
+	Column
+	value   func(v T) any
+	element func(v T, i int) any
+}
+
+// table is the full column set of a record type.
+type table[T any] struct {
+	columns  []column[T]
+	defaults []string
+	// groups returns the number of elements of each repeated field.
+	groups map[string]func(v T) int
+}
+
+func scalar[T any](name, description string, value func(v T) any) column[T] {
+	return column[T]{Column: Column{Name: name, Description: description}, value: value}
+}
+
+func repeated[T any](group, name, description string, element func(v T, i int) any) column[T] {
+	return column[T]{Column: Column{Name: name, Group: group, Description: description}, element: element}
+}
+
+// timestamp formats a Unix timestamp as RFC 3339, or "" for zero.
+func timestamp(v int64) any {
+	t := synthient.UnixTime(v)
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+type ip = synthient.IP
+
+var ipTable = table[ip]{
+	columns: []column[ip]{
+		scalar("ip", "IP address", func(v ip) any { return v.IP }),
+		scalar("network_asn", "autonomous system number", func(v ip) any { return v.Network.Asn }),
+		scalar("network_isp", "ISP name", func(v ip) any { return v.Network.Isp }),
+		scalar("network_type", "network type, e.g. isp or hosting", func(v ip) any { return v.Network.Type }),
+		scalar("network_org", "organization owning the network", func(v ip) any { return v.Network.Org }),
+		scalar("network_abuse_email", "abuse contact email", func(v ip) any { return v.Network.AbuseEmail }),
+		scalar("network_abuse_phone", "abuse contact phone", func(v ip) any { return v.Network.AbusePhone }),
+		scalar("network_domain", "network domain", func(v ip) any { return v.Network.Domain }),
+		scalar("location_country", "country code", func(v ip) any { return v.Location.Country }),
+		scalar("location_state", "state or region", func(v ip) any { return v.Location.State }),
+		scalar("location_city", "city", func(v ip) any { return v.Location.City }),
+		scalar("location_timezone", "IANA time zone", func(v ip) any { return v.Location.Timezone }),
+		scalar("location_latitude", "latitude in degrees", func(v ip) any { return v.Location.Latitude }),
+		scalar("location_longitude", "longitude in degrees", func(v ip) any { return v.Location.Longitude }),
+		scalar("location_geo_hash", "geohash of the location", func(v ip) any { return v.Location.GeoHash }),
+		scalar("intelligence_risk_score", "risk score, 0 to 100", func(v ip) any { return v.Intelligence.RiskScore }),
+		repeated("behavior", "intelligence_behavior", "observed behavior", func(v ip, i int) any {
+			return v.Intelligence.Behavior[i]
+		}),
+		repeated("categories", "intelligence_categories", "category", func(v ip, i int) any {
+			return v.Intelligence.Categories[i]
+		}),
+		repeated("devices", "intelligence_devices_os", "device operating system", func(v ip, i int) any {
+			return v.Intelligence.Devices[i].OS
+		}),
+		repeated("devices", "intelligence_devices_version", "device operating system version", func(v ip, i int) any {
+			return v.Intelligence.Devices[i].Version
+		}),
+		repeated("providers", "intelligence_providers_provider", "provider seen using the IP", func(v ip, i int) any {
+			return v.Intelligence.Providers[i].Provider
+		}),
+		repeated("providers", "intelligence_providers_type", "provider category", func(v ip, i int) any {
+			return v.Intelligence.Providers[i].Type
+		}),
+		repeated("providers", "intelligence_providers_last_seen", "when the provider was last seen, RFC 3339", func(v ip, i int) any {
+			return timestamp(v.Intelligence.Providers[i].LastSeen)
+		}),
+		scalar("is_proxy", "IP.IsProxy", func(v ip) any { return v.IsProxy() }),
+		scalar("is_residential_proxy", "IP.IsResidentialProxy", func(v ip) any { return v.IsResidentialProxy() }),
+		scalar("is_vpn", "IP.IsVPN", func(v ip) any { return v.IsVPN() }),
+		scalar("is_tor", "IP.IsTor", func(v ip) any { return v.IsTor() }),
+		scalar("is_hosting", "IP.IsHosting", func(v ip) any { return v.IsHosting() }),
+	},
+	groups: map[string]func(v ip) int{
+		"behavior":   func(v ip) int { return len(v.Intelligence.Behavior) },
+		"categories": func(v ip) int { return len(v.Intelligence.Categories) },
+		"devices":    func(v ip) int { return len(v.Intelligence.Devices) },
+		"providers":  func(v ip) int { return len(v.Intelligence.Providers) },
+	},
+}
+
+type domain = synthient.Domain
+
+var domainTable = table[domain]{
+	columns: []column[domain]{
+		scalar("domain", "domain name", func(v domain) any { return v.Domain }),
+		scalar("status", "status", func(v domain) any { return v.Status }),
+		scalar("stats_events_24h", "events in the last 24 hours", func(v domain) any { return v.Stats.Events24H }),
+		scalar("stats_total_events_30d", "events in the last 30 days", func(v domain) any { return v.Stats.TotalEvents30D }),
+		scalar("unique_ips_value_24h", "unique IPs in the last 24 hours", func(v domain) any { return v.UniqueIPs.Value24H }),
+		scalar("unique_ips_value_30d", "unique IPs in the last 30 days", func(v domain) any { return v.UniqueIPs.Value30D }),
+		repeated("sparkline", "unique_ips_sparkline_24h", "hourly unique IPs over the last 24 hours", func(v domain, i int) any {
+			return v.UniqueIPs.Sparkline24H[i]
+		}),
+		scalar("top_asn_asn", "ASN with the most events", func(v domain) any { return v.TopASN.ASN }),
+		scalar("top_asn_events", "events from top_asn_asn", func(v domain) any { return v.TopASN.Events }),
+		repeated("top_subdomains", "top_subdomains_subdomain", "subdomain", func(v domain, i int) any {
+			return v.TopSubdomains[i].Subdomain
+		}),
+		repeated("top_subdomains", "top_subdomains_count", "events for the subdomain", func(v domain, i int) any {
+			return v.TopSubdomains[i].Count
+		}),
+		repeated("top_ports", "top_ports_port", "port", func(v domain, i int) any { return v.TopPorts[i].Port }),
+		repeated("top_ports", "top_ports_count", "events on the port", func(v domain, i int) any { return v.TopPorts[i].Count }),
+		repeated("geo_distribution", "geo_distribution_country_code", "country code", func(v domain, i int) any {
+			return v.GeoDistribution[i].CountryCode
+		}),
+		repeated("geo_distribution", "geo_distribution_unique_ips", "unique IPs from the country", func(v domain, i int) any {
+			return v.GeoDistribution[i].UniqueIPs
+		}),
+		repeated("geo_distribution", "geo_distribution_events", "events from the country", func(v domain, i int) any {
+			return v.GeoDistribution[i].Events
+		}),
+		scalar("activity_stats_peak_hour", "busiest hour of the day", func(v domain) any { return v.ActivityStats.PeakHour }),
+		scalar("activity_stats_quiet_hour", "quietest hour of the day", func(v domain) any { return v.ActivityStats.QuietHour }),
+		scalar("activity_stats_median_per_hour", "median events per hour", func(v domain) any { return v.ActivityStats.MedianPerHour }),
+		scalar("activity_stats_p95_per_hour", "95th percentile events per hour", func(v domain) any { return v.ActivityStats.P95PerHour }),
+		scalar("activity_stats_cadence", "activity cadence", func(v domain) any { return v.ActivityStats.Cadence }),
+		repeated("time_series", "time_series_date", "start of the hour, RFC 3339", func(v domain, i int) any {
+			return timestamp(int64(v.TimeSeries[i].Date))
+		}),
+		repeated("time_series", "time_series_events", "events in the hour", func(v domain, i int) any {
+			return v.TimeSeries[i].Events
+		}),
+		repeated("time_series", "time_series_unique_ips", "unique IPs in the hour", func(v domain, i int) any {
+			return v.TimeSeries[i].UniqueIPs
+		}),
+		repeated("recent_events", "recent_events_timestamp", "event time, RFC 3339", func(v domain, i int) any {
+			return timestamp(int64(v.RecentEvents[i].Timestamp))
+		}),
+		repeated("recent_events", "recent_events_source_ip_masked", "masked source IP", func(v domain, i int) any {
+			return v.RecentEvents[i].SourceIPMasked
+		}),
+		repeated("recent_events", "recent_events_target_subdomain", "targeted subdomain", func(v domain, i int) any {
+			return v.RecentEvents[i].TargetSubdomain
+		}),
+		repeated("recent_events", "recent_events_port", "targeted port", func(v domain, i int) any {
+			return v.RecentEvents[i].Port
+		}),
+		repeated("recent_events", "recent_events_country_code", "source country code", func(v domain, i int) any {
+			return v.RecentEvents[i].CountryCode
+		}),
+	},
+	defaults: []string{
+		"domain", "status", "stats_events_24h", "stats_total_events_30d", "unique_ips_value_24h",
+		"unique_ips_value_30d", "top_asn_asn", "top_asn_events", "top_subdomains_subdomain",
+		"top_subdomains_count", "top_ports_port", "top_ports_count", "geo_distribution_country_code",
+		"geo_distribution_unique_ips", "geo_distribution_events", "activity_stats_peak_hour",
+		"activity_stats_quiet_hour", "activity_stats_median_per_hour", "activity_stats_p95_per_hour",
+		"activity_stats_cadence",
+	},
+	groups: map[string]func(v domain) int{
+		"sparkline":        func(v domain) int { return len(v.UniqueIPs.Sparkline24H) },
+		"top_subdomains":   func(v domain) int { return len(v.TopSubdomains) },
+		"top_ports":        func(v domain) int { return len(v.TopPorts) },
+		"geo_distribution": func(v domain) int { return len(v.GeoDistribution) },
+		"time_series":      func(v domain) int { return len(v.TimeSeries) },
+		"recent_events":    func(v domain) int { return len(v.RecentEvents) },
+	},
+}
+
+// IPColumns returns every column available for IP records, in their default order.
+// All of them are exported by default.
+func IPColumns() []Column { return ipTable.describe() }
+
+// DomainColumns returns every column available for Domain records. By default the
+// sparkline, time_series and recent_events groups are left out; select their columns
+// explicitly to export them.
+func DomainColumns() []Column { return domainTable.describe() }
+
+func (t *table[T]) describe() []Column {
+	columns := make([]Column, len(t.columns))
+	for i, c := range t.columns {
+		columns[i] = c.Column
+	}
+	return columns
+}
@@ -0,0 +1,313 @@
+// Package export flattens IP and Domain lookups into rows for spreadsheets and data
+// warehouses, written as CSV, TSV or JSONL.
+//
+// Every field of the nested lookup structs becomes a column named after its JSON
+// path, joined with '_' (e.g. "network_asn", "intelligence_risk_score",
+// "top_subdomains_count"); IPColumns and DomainColumns list them with descriptions.
+// The names are stable: new API fields only ever add columns. Timestamps are written
+// as RFC 3339. IP records also get derived is_proxy, is_residential_proxy, is_vpn,
+// is_tor and is_hosting columns.
+//
+// Columns from a repeated field, such as Behavior, Categories or Providers[], form a
+// group. By default a record is one row and each repeated column holds all of its
+// values: joined with Options.Delimiter in CSV and TSV, or as a JSON array in JSONL.
+// Setting Options.Explode to a group instead writes one row per element of that group
+// (one row when it is empty), repeating the other columns.
+//
+// Example:
+//
+//	enc, err := export.NewIPEncoder(os.Stdout, &export.Options{
+//		Format:  export.FormatCSV,
+//		Columns: []string{"ip", "intelligence_risk_score", "intelligence_providers_provider"},
+//		Explode: "providers",
+//	})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, ip := range results {
+//		err = enc.Encode(ip)
+//		...
+//	}
+//	err = enc.Flush()
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/synthient/go-synthient/v2"
+)
+
+// Format is an output format.
+type Format string
+
+// Supported formats.
+const (
+	FormatCSV   Format = "csv"
+	FormatTSV   Format = "tsv"
+	FormatJSONL Format = "jsonl"
+)
+
+// DefaultDelimiter joins the values of repeated columns in CSV and TSV output unless
+// Options.Delimiter says otherwise.
+const DefaultDelimiter = ";"
+
+// Options configures an Encoder.
+type Options struct {
+	// Format is the output format. Defaults to FormatCSV.
+	Format Format
+	// Columns selects and orders the columns. Defaults to the record type's default
+	// columns; see IPColumns and DomainColumns.
+	Columns []string
+	// Delimiter joins the values of repeated columns in CSV and TSV. Defaults to
+	// DefaultDelimiter.
+	Delimiter string
+	// Explode is a group whose elements are written as separate rows. Empty joins
+	// every group.
+	Explode string
+	// NoHeader omits the header row of CSV and TSV output.
+	NoHeader bool
+}
+
+// Flattener turns records of type T into rows of the selected columns.
+type Flattener[T any] struct {
+	table   *table[T]
+	columns []column[T]
+	names   []string
+	explode string
+}
+
+// NewIPFlattener returns a Flattener for IP records. Nil columns selects every IP
+// column.
+func NewIPFlattener(columns []string, explode string) (*Flattener[synthient.IP], error) {
+	return newFlattener(&ipTable, columns, explode)
+}
+
+// NewDomainFlattener returns a Flattener for Domain records. Nil columns selects the
+// default Domain columns.
+func NewDomainFlattener(columns []string, explode string) (*Flattener[synthient.Domain], error) {
+	return newFlattener(&domainTable, columns, explode)
+}
+
+func newFlattener[T any](t *table[T], names []string, explode string) (*Flattener[T], error) {
+	if len(names) == 0 {
+		names = t.defaults
+	}
+	if len(names) == 0 {
+		for _, c := range t.columns {
+			names = append(names, c.Name)
+		}
+	}
+	if _, ok := t.groups[explode]; explode != "" && !ok {
+		return nil, fmt.Errorf("unknown group %q", explode)
+	}
+	f := &Flattener[T]{table: t, explode: explode}
+	for _, name := range names {
+		i := indexColumn(t.columns, name)
+		if i < 0 {
+			return nil, fmt.Errorf("unknown column %q", name)
+		}
+		f.columns = append(f.columns, t.columns[i])
+		f.names = append(f.names, name)
+	}
+	return f, nil
+}
+
+func indexColumn[T any](columns []column[T], name string) int {
+	for i, c := range columns {
+		if c.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Columns returns the names of the selected columns, in order.
+func (f *Flattener[T]) Columns() []string { return slices.Clone(f.names) }
+
+// Rows flattens v. Values are strings, ints, float64s and bools; repeated columns
+// that are not exploded hold a []any of their values. Exploded columns of an empty
+// group are nil.
+func (f *Flattener[T]) Rows(v T) [][]any {
+	n := 1
+	if f.explode != "" {
+		n = max(1, f.table.groups[f.explode](v))
+	}
+	rows := make([][]any, n)
+	for r := range rows {
+		row := make([]any, len(f.columns))
+		for i, c := range f.columns {
+			switch {
+			case c.value != nil:
+				row[i] = c.value(v)
+			case c.Group == f.explode:
+				if r < f.table.groups[c.Group](v) {
+					row[i] = c.element(v, r)
+				}
+			default:
+				values := make([]any, f.table.groups[c.Group](v))
+				for j := range values {
+					values[j] = c.element(v, j)
+				}
+				row[i] = values
+			}
+		}
+		rows[r] = row
+	}
+	return rows
+}
+
+// FormatValue formats a value from Rows as text, joining the values of a repeated
+// column with delimiter. Nil formats as "".
+func FormatValue(value any, delimiter string) string {
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case int:
+		return strconv.Itoa(value)
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(value)
+	case []any:
+		parts := make([]string, len(value))
+		for i, v := range value {
+			parts[i] = FormatValue(v, delimiter)
+		}
+		return strings.Join(parts, delimiter)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// appendJSON appends a JSON object of columns and their values from Rows to buf.
+func appendJSON(buf *bytes.Buffer, columns []string, row []any) {
+	buf.WriteByte('{')
+	for i, name := range columns {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(name)
+		value, err := json.Marshal(row[i])
+		if err != nil {
+			value = []byte("null")
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+}
+
+// Encoder writes flattened records of type T. Output is buffered; call Flush when
+// done.
+type Encoder[T any] struct {
+	flattener *Flattener[T]
+	format    Format
+	delimiter string
+	header    bool
+
+	out *bufio.Writer
+	csv *csv.Writer
+	buf bytes.Buffer
+}
+
+// NewIPEncoder returns an Encoder writing IP records to w.
+func NewIPEncoder(w io.Writer, options *Options) (*Encoder[synthient.IP], error) {
+	return newEncoder(&ipTable, w, options)
+}
+
+// NewDomainEncoder returns an Encoder writing Domain records to w.
+func NewDomainEncoder(w io.Writer, options *Options) (*Encoder[synthient.Domain], error) {
+	return newEncoder(&domainTable, w, options)
+}
+
+func newEncoder[T any](t *table[T], w io.Writer, options *Options) (*Encoder[T], error) {
+	var opts Options
+	if options != nil {
+		opts = *options
+	}
+	flattener, err := newFlattener(t, opts.Columns, opts.Explode)
+	if err != nil {
+		return nil, err
+	}
+	enc := &Encoder[T]{
+		flattener: flattener,
+		format:    cmp.Or(opts.Format, FormatCSV),
+		delimiter: cmp.Or(opts.Delimiter, DefaultDelimiter),
+		header:    !opts.NoHeader,
+		out:       bufio.NewWriter(w),
+	}
+	switch enc.format {
+	case FormatCSV:
+		enc.csv = csv.NewWriter(enc.out)
+	case FormatTSV:
+		enc.csv = csv.NewWriter(enc.out)
+		enc.csv.Comma = '\t'
+	case FormatJSONL:
+		enc.header = false
+	default:
+		return nil, fmt.Errorf("unknown format %q", enc.format)
+	}
+	return enc, nil
+}
+
+// Encode writes the rows of v.
+func (enc *Encoder[T]) Encode(v T) error {
+	err := enc.writeHeader()
+	if err != nil {
+		return err
+	}
+	for _, row := range enc.flattener.Rows(v) {
+		if enc.csv != nil {
+			record := make([]string, len(row))
+			for i, value := range row {
+				record[i] = FormatValue(value, enc.delimiter)
+			}
+			err = enc.csv.Write(record)
+		} else {
+			enc.buf.Reset()
+			appendJSON(&enc.buf, enc.flattener.names, row)
+			enc.buf.WriteByte('\n')
+			_, err = enc.out.Write(enc.buf.Bytes())
+		}
+		if err != nil {
+			return fmt.Errorf("writing row: %w", err)
+		}
+	}
+	return nil
+}
+
+// Flush writes any buffered output, including the header if nothing was encoded.
+func (enc *Encoder[T]) Flush() error {
+	err := enc.writeHeader()
+	if err == nil && enc.csv != nil {
+		enc.csv.Flush()
+		err = enc.csv.Error()
+	}
+	if err == nil {
+		err = enc.out.Flush()
+	}
+	if err != nil {
+		return fmt.Errorf("flushing output: %w", err)
+	}
+	return nil
+}
+
+func (enc *Encoder[T]) writeHeader() error {
+	if !enc.header {
+		return nil
+	}
+	enc.header = false
+	return enc.csv.Write(enc.flattener.names)
+}
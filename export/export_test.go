@@ -0,0 +1,158 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/synthient/go-synthient/v2"
+)
+
+func testIP(t *testing.T) synthient.IP {
+	t.Helper()
+	var ip synthient.IP
+	err := json.Unmarshal([]byte(`{
+		"ip": "213.149.183.77",
+		"network": {"asn": 64500, "type": "hosting"},
+		"location": {"country": "DE", "latitude": 52.52},
+		"intelligence": {
+			"risk_score": 90,
+			"behavior": ["SCANNING"],
+			"categories": ["VPN", "RESIDENTIAL_PROXY"],
+			"providers": [
+				{"provider": "alpha", "type": "VPN", "last_seen": 1773100000},
+				{"provider": "beta", "type": "RESIDENTIAL_PROXY", "last_seen": 0}
+			]
+		}
+	}`), &ip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ip
+}
+
+var testColumns = []string{"ip", "network_asn", "intelligence_categories", "intelligence_providers_provider", "intelligence_providers_last_seen", "is_vpn"}
+
+func TestEncoderCSVJoined(t *testing.T) {
+	var out bytes.Buffer
+	enc, err := NewIPEncoder(&out, &Options{Columns: testColumns, Delimiter: "|"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = enc.Encode(testIP(t))
+	if err == nil {
+		err = enc.Flush()
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "ip,network_asn,intelligence_categories,intelligence_providers_provider,intelligence_providers_last_seen,is_vpn\n" +
+		"213.149.183.77,64500,VPN|RESIDENTIAL_PROXY,alpha|beta,2026-03-09T23:46:40Z|,true\n"
+	if out.String() != want {
+		t.Errorf("output =\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestEncoderTSVExploded(t *testing.T) {
+	var out bytes.Buffer
+	enc, err := NewIPEncoder(&out, &Options{Format: FormatTSV, Columns: testColumns, Explode: "providers", NoHeader: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var empty synthient.IP
+	empty.IP = "213.149.183.127"
+	for _, ip := range []synthient.IP{testIP(t), empty} {
+		err = enc.Encode(ip)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = enc.Flush()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"213.149.183.77\t64500\tVPN;RESIDENTIAL_PROXY\talpha\t2026-03-09T23:46:40Z\ttrue",
+		"213.149.183.77\t64500\tVPN;RESIDENTIAL_PROXY\tbeta\t\ttrue",
+		"213.149.183.127\t0\t\t\t\tfalse",
+	}
+	if got := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n"); !slices.Equal(got, want) {
+		t.Errorf("rows =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestEncoderJSONL(t *testing.T) {
+	var out bytes.Buffer
+	enc, err := NewIPEncoder(&out, &Options{Format: FormatJSONL, Columns: []string{"ip", "location_latitude", "intelligence_behavior"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = enc.Encode(testIP(t))
+	if err == nil {
+		err = enc.Flush()
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"ip":"213.149.183.77","location_latitude":52.52,"intelligence_behavior":["SCANNING"]}` + "\n"
+	if out.String() != want {
+		t.Errorf("output = %s, want %s", out.String(), want)
+	}
+}
+
+func TestDomainEncoderDefaults(t *testing.T) {
+	var domain synthient.Domain
+	domain.Domain = "example.com"
+	domain.TopPorts = append(domain.TopPorts, struct {
+		Port  int `json:"port"`
+		Count int `json:"count"`
+	}{Port: 443, Count: 7})
+
+	var out bytes.Buffer
+	enc, err := NewDomainEncoder(&out, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = enc.Encode(domain)
+	if err == nil {
+		err = enc.Flush()
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(out.String(), "\n")
+	if !strings.HasPrefix(lines[0], "domain,status,stats_events_24h,") || strings.Contains(lines[0], "recent_events") {
+		t.Errorf("header = %s", lines[0])
+	}
+	if !strings.Contains(lines[1], ",443,7,") {
+		t.Errorf("row = %s", lines[1])
+	}
+}
+
+func TestColumnsAreUniqueAndGrouped(t *testing.T) {
+	check := func(columns []Column, groups []string) {
+		seen := map[string]bool{}
+		for _, c := range columns {
+			if seen[c.Name] {
+				t.Errorf("duplicate column %s", c.Name)
+			}
+			seen[c.Name] = true
+			if c.Group != "" && !slices.Contains(groups, c.Group) {
+				t.Errorf("column %s has unknown group %s", c.Name, c.Group)
+			}
+		}
+	}
+	check(IPColumns(), []string{"behavior", "categories", "devices", "providers"})
+	check(DomainColumns(), []string{"sparkline", "top_subdomains", "top_ports", "geo_distribution", "time_series", "recent_events"})
+
+	_, err := NewIPEncoder(&bytes.Buffer{}, &Options{Columns: []string{"risk_score"}})
+	if err == nil {
+		t.Error("unknown column accepted")
+	}
+	_, err = NewIPEncoder(&bytes.Buffer{}, &Options{Explode: "top_ports"})
+	if err == nil {
+		t.Error("domain group accepted for IPs")
+	}
+}
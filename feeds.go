@@ -88,7 +88,13 @@ func (client *Client) FeedSnapshots(
 		req.URL.RawQuery = q.Encode()
 	}
 
-	resp, err := requestJSON[FeedSnapshotsPage](requestOptions, client, req, http.StatusOK)
+	resp, err := requestJSON[FeedSnapshotsPage](
+		requestOptions,
+		client,
+		&Call{Operation: "FeedSnapshots", Stream: stream},
+		req,
+		http.StatusOK,
+	)
 	if err != nil {
 		return FeedSnapshotsPage{}, fmt.Errorf("requesting JSON data: %w", err)
 	}
@@ -154,7 +160,13 @@ func (client *Client) FeedSnapshotMeta(
 		)
 	}
 
-	resp, err := requestJSON[FeedSnapshotMeta](requestOptions, client, req, http.StatusOK)
+	resp, err := requestJSON[FeedSnapshotMeta](
+		requestOptions,
+		client,
+		&Call{Operation: "FeedSnapshotMeta", Stream: stream, Date: date},
+		req,
+		http.StatusOK,
+	)
 	if err != nil {
 		return FeedSnapshotMeta{}, fmt.Errorf("requesting JSON data: %w", err)
 	}
@@ -182,6 +194,7 @@ func feedStreamPath(stream string) []string {
 func downloadFeed(
 	client *Client,
 	requestOptions *RequestOptions,
+	call *Call,
 	date string,
 	hour *int,
 	filename string,
@@ -203,7 +216,9 @@ func downloadFeed(
 		return nil, fmt.Errorf("making request for %s download (%s): %w", label, date, err)
 	}
 
-	body, err := request(requestOptions, client, req, http.StatusOK)
+	call.Date = date
+	call.Hour = hour
+	body, err := request(requestOptions, client, call, req, http.StatusOK)
 	if err != nil {
 		return nil, fmt.Errorf("requesting %s snapshot: %w", label, err)
 	}
@@ -262,5 +277,6 @@ func (client *Client) DownloadFeedSnapshot(
 	requestOptions *RequestOptions,
 ) (io.ReadCloser, error) {
 	segments := append([]string{"feeds"}, feedStreamPath(stream)...)
-	return downloadFeed(client, requestOptions, date, hour, filename, segments...)
+	call := &Call{Operation: "DownloadFeedSnapshot", Stream: stream}
+	return downloadFeed(client, requestOptions, call, date, hour, filename, segments...)
 }
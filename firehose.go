@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"io"
 	"iter"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"time"
 )
 
 // ProxyEvent is a single observation delivered by the proxies real-time stream.
@@ -50,10 +52,17 @@ type AnonymizerEvent struct {
 	Timestamp  int64  `json:"timestamp"`
 }
 
-func streamFeed[T any](client *Client, requestOptions *RequestOptions, pathSegments ...string) iter.Seq2[T, error] {
+func streamFeed[T any](
+	client *Client,
+	requestOptions *RequestOptions,
+	operation, stream string,
+	pathSegments ...string,
+) iter.Seq2[T, error] {
 	label := pathSegments[len(pathSegments)-2] // e.g. "proxies", "http"
 	return func(yield func(T, error) bool) {
 		var zero T
+		// each range opens its own connection, so it gets its own call
+		call := &Call{Operation: operation, Stream: stream}
 
 		path, err := url.JoinPath(client.BaseAPI.String(), pathSegments...)
 		if err != nil {
@@ -67,21 +76,42 @@ func streamFeed[T any](client *Client, requestOptions *RequestOptions, pathSegme
 			return
 		}
 
-		body, err := request(requestOptions, client, req, http.StatusOK)
+		body, err := request(requestOptions, client, call, req, http.StatusOK)
 		if err != nil {
 			yield(zero, fmt.Errorf("connecting to %s stream: %w", label, err))
 			return
 		}
 		defer func() { _ = body.Close() }()
 
+		ctx := requestOptions.context()
+		client.log(ctx, slog.LevelInfo, "synthient stream connected", call)
+		events := 0
+		defer func() {
+			client.log(ctx, slog.LevelInfo, "synthient stream disconnected", call,
+				slog.Int("events", events),
+				slog.Duration("duration", time.Since(call.Start)),
+			)
+		}()
+
+		observer, _ := body.(DecodeObserver)
 		dec := json.NewDecoder(body)
 		for dec.More() {
 			var event T
 			err = dec.Decode(&event)
+			if observer != nil {
+				observer.Decoded(err)
+			}
 			if err != nil {
+				if ctx.Err() == nil {
+					client.log(ctx, slog.LevelError, "decoding synthient stream event failed", call,
+						slog.Int("events", events),
+						slog.String("error", err.Error()),
+					)
+				}
 				yield(zero, fmt.Errorf("decoding %s stream event: %w", label, err))
 				return
 			}
+			events++
 			if !yield(event, nil) {
 				return
 			}
@@ -102,7 +132,10 @@ func streamFeed[T any](client *Client, requestOptions *RequestOptions, pathSegme
 //		fmt.Printf("%s %s %s\n", event.IP, event.Provider, event.CountryCode)
 //	}
 func (client *Client) StreamProxy(requestOptions *RequestOptions) iter.Seq2[ProxyEvent, error] {
-	return streamFeed[ProxyEvent](client, requestOptions, "feeds", "proxies", "stream")
+	return streamFeed[ProxyEvent](
+		client, requestOptions, "StreamProxy", "proxies",
+		"feeds", "proxies", "stream",
+	)
 }
 
 // StreamAnonymizer connects to the real-time anonymizer stream and returns an iterator
@@ -120,7 +153,10 @@ func (client *Client) StreamProxy(requestOptions *RequestOptions) iter.Seq2[Prox
 //		fmt.Printf("%s-%s %s %s\n", event.RangeStart, event.RangeEnd, event.Type, event.Provider)
 //	}
 func (client *Client) StreamAnonymizer(requestOptions *RequestOptions) iter.Seq2[AnonymizerEvent, error] {
-	return streamFeed[AnonymizerEvent](client, requestOptions, "feeds", "anonymizers", "stream")
+	return streamFeed[AnonymizerEvent](
+		client, requestOptions, "StreamAnonymizer", "anonymizers",
+		"feeds", "anonymizers", "stream",
+	)
 }
 
 // StreamTorrent connects to the real-time torrent stream and returns an iterator that
@@ -138,7 +174,10 @@ func (client *Client) StreamAnonymizer(requestOptions *RequestOptions) iter.Seq2
 //		fmt.Printf("%s %s %d peers\n", event.InfoHash, event.Name, len(event.Peers))
 //	}
 func (client *Client) StreamTorrent(requestOptions *RequestOptions) iter.Seq2[TorrentEvent, error] {
-	return streamFeed[TorrentEvent](client, requestOptions, "feeds", "torrents", "stream")
+	return streamFeed[TorrentEvent](
+		client, requestOptions, "StreamTorrent", "torrents",
+		"feeds", "torrents", "stream",
+	)
 }
 
 // DownloadProxy downloads a proxy feed Parquet snapshot. If filename is non-empty the
@@ -167,7 +206,8 @@ func (client *Client) DownloadProxy(
 	filename string,
 	requestOptions *RequestOptions,
 ) (io.ReadCloser, error) {
-	return downloadFeed(client, requestOptions, date, hour, filename, "feeds", "proxies")
+	call := &Call{Operation: "DownloadProxy", Stream: "proxies"}
+	return downloadFeed(client, requestOptions, call, date, hour, filename, "feeds", "proxies")
 }
 
 // DownloadAnonymizer downloads an anonymizer feed Parquet snapshot. If filename is
@@ -196,7 +236,8 @@ func (client *Client) DownloadAnonymizer(
 	filename string,
 	requestOptions *RequestOptions,
 ) (io.ReadCloser, error) {
-	return downloadFeed(client, requestOptions, date, hour, filename, "feeds", "anonymizers")
+	call := &Call{Operation: "DownloadAnonymizer", Stream: "anonymizers"}
+	return downloadFeed(client, requestOptions, call, date, hour, filename, "feeds", "anonymizers")
 }
 
 // DownloadTorrent downloads a torrent feed Parquet snapshot. If filename is non-empty the
@@ -225,5 +266,6 @@ func (client *Client) DownloadTorrent(
 	filename string,
 	requestOptions *RequestOptions,
 ) (io.ReadCloser, error) {
-	return downloadFeed(client, requestOptions, date, hour, filename, "feeds", "torrents")
+	call := &Call{Operation: "DownloadTorrent", Stream: "torrents"}
+	return downloadFeed(client, requestOptions, call, date, hour, filename, "feeds", "torrents")
 }
@@ -0,0 +1,185 @@
+// Package geo works with the locations in IP lookups: great-circle distances,
+// geohash encoding and decoding, and impossible-travel detection over a user's logins.
+//
+// Example:
+//
+//	a, _ := client.GetIP("213.149.183.127", nil)
+//	b, _ := client.GetIP("8.8.8.8", nil)
+//	km, ok := geo.Distance(a, b)
+//
+//	for _, trip := range geo.ImpossibleTravel(logins, nil) {
+//		log.Printf("%s -> %s: %.0f km in %s (%.0f km/h)",
+//			trip.From.IP.IP, trip.To.IP.IP, trip.DistanceKm, trip.Elapsed, trip.SpeedKmh)
+//	}
+package geo
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/synthient/go-synthient/v2"
+)
+
+// EarthRadiusKm is the mean radius of the Earth used for distances.
+const EarthRadiusKm = 6371.0088
+
+// Point is a latitude and longitude in degrees.
+type Point struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// Valid reports whether p is within the latitude and longitude ranges.
+func (p Point) Valid() bool {
+	return p.Lat >= -90 && p.Lat <= 90 && p.Lon >= -180 && p.Lon <= 180 &&
+		!math.IsNaN(p.Lat) && !math.IsNaN(p.Lon)
+}
+
+// DistanceKm returns the great-circle distance from p to q in kilometres, using the
+// haversine formula.
+func (p Point) DistanceKm(q Point) float64 {
+	lat1, lat2 := radians(p.Lat), radians(q.Lat)
+	dLat := lat2 - lat1
+	dLon := radians(q.Lon - p.Lon)
+	h := math.Pow(math.Sin(dLat/2), 2) + math.Cos(lat1)*math.Cos(lat2)*math.Pow(math.Sin(dLon/2), 2)
+	return 2 * EarthRadiusKm * math.Asin(math.Sqrt(min(h, 1)))
+}
+
+func radians(deg float64) float64 { return deg * math.Pi / 180 }
+
+// Locate returns the location of an IP lookup. Location.Latitude and
+// Location.Longitude are used when set; otherwise Location.GeoHash is decoded. ok is
+// false when the lookup has no usable location.
+func Locate(ip synthient.IP) (p Point, ok bool) {
+	p = Point{Lat: ip.Location.Latitude, Lon: ip.Location.Longitude}
+	if (p.Lat != 0 || p.Lon != 0) && p.Valid() {
+		return p, true
+	}
+	if ip.Location.GeoHash != "" {
+		box, err := DecodeGeoHash(ip.Location.GeoHash)
+		if err == nil {
+			return box.Center(), true
+		}
+	}
+	return Point{}, false
+}
+
+// Distance returns the great-circle distance between two lookups in kilometres. ok is
+// false when either has no usable location.
+func Distance(a, b synthient.IP) (km float64, ok bool) {
+	p, ok := Locate(a)
+	if !ok {
+		return 0, false
+	}
+	q, ok := Locate(b)
+	if !ok {
+		return 0, false
+	}
+	return p.DistanceKm(q), true
+}
+
+// ErrInvalidGeoHash is returned by DecodeGeoHash for empty input or characters outside
+// the geohash alphabet.
+var ErrInvalidGeoHash = errors.New("invalid geohash")
+
+const geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// MaxGeoHashPrecision is the longest geohash EncodeGeoHash produces. Twelve characters
+// resolve to a few centimetres.
+const MaxGeoHashPrecision = 12
+
+// Box is the area a geohash covers.
+type Box struct {
+	MinLat, MaxLat float64
+	MinLon, MaxLon float64
+}
+
+// Center returns the middle of b.
+func (b Box) Center() Point {
+	return Point{Lat: (b.MinLat + b.MaxLat) / 2, Lon: (b.MinLon + b.MaxLon) / 2}
+}
+
+// Contains reports whether p is inside b.
+func (b Box) Contains(p Point) bool {
+	return p.Lat >= b.MinLat && p.Lat <= b.MaxLat && p.Lon >= b.MinLon && p.Lon <= b.MaxLon
+}
+
+// EncodeGeoHash returns the geohash of p with precision characters, clamped to
+// 1..MaxGeoHashPrecision.
+func EncodeGeoHash(p Point, precision int) string {
+	precision = max(1, min(precision, MaxGeoHashPrecision))
+	box := Box{MinLat: -90, MaxLat: 90, MinLon: -180, MaxLon: 180}
+	var hash strings.Builder
+	hash.Grow(precision)
+	even := true
+	for hash.Len() < precision {
+		var index byte
+		for range 5 {
+			index <<= 1
+			if even {
+				mid := (box.MinLon + box.MaxLon) / 2
+				if p.Lon >= mid {
+					index |= 1
+					box.MinLon = mid
+				} else {
+					box.MaxLon = mid
+				}
+			} else {
+				mid := (box.MinLat + box.MaxLat) / 2
+				if p.Lat >= mid {
+					index |= 1
+					box.MinLat = mid
+				} else {
+					box.MaxLat = mid
+				}
+			}
+			even = !even
+		}
+		hash.WriteByte(geohashAlphabet[index])
+	}
+	return hash.String()
+}
+
+// DecodeGeoHash returns the area covered by a geohash. Decoding ignores case.
+func DecodeGeoHash(hash string) (Box, error) {
+	if hash == "" {
+		return Box{}, fmt.Errorf("%w: empty", ErrInvalidGeoHash)
+	}
+	box := Box{MinLat: -90, MaxLat: 90, MinLon: -180, MaxLon: 180}
+	even := true
+	for i := range len(hash) {
+		index := strings.IndexByte(geohashAlphabet, lower(hash[i]))
+		if index < 0 {
+			return Box{}, fmt.Errorf("%w %q: character %q", ErrInvalidGeoHash, hash, hash[i])
+		}
+		for bit := 4; bit >= 0; bit-- {
+			set := index>>bit&1 == 1
+			if even {
+				mid := (box.MinLon + box.MaxLon) / 2
+				if set {
+					box.MinLon = mid
+				} else {
+					box.MaxLon = mid
+				}
+			} else {
+				mid := (box.MinLat + box.MaxLat) / 2
+				if set {
+					box.MinLat = mid
+				} else {
+					box.MaxLat = mid
+				}
+			}
+			even = !even
+		}
+	}
+	return box, nil
+}
+
+func lower(c byte) byte {
+	if c >= 'A' && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
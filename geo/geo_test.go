@@ -0,0 +1,115 @@
+package geo
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/synthient/go-synthient/v2"
+)
+
+var (
+	london  = Point{Lat: 51.5074, Lon: -0.1278}
+	newYork = Point{Lat: 40.7128, Lon: -74.0060}
+	paris   = Point{Lat: 48.8566, Lon: 2.3522}
+)
+
+func TestDistanceKm(t *testing.T) {
+	if km := london.DistanceKm(newYork); math.Abs(km-5570) > 10 {
+		t.Errorf("London-New York = %.1f km, want about 5570", km)
+	}
+	if km := london.DistanceKm(london); km != 0 {
+		t.Errorf("London-London = %f km", km)
+	}
+	antipode := Point{Lat: -london.Lat, Lon: london.Lon + 180}
+	if km := london.DistanceKm(antipode); math.Abs(km-math.Pi*EarthRadiusKm) > 1 {
+		t.Errorf("antipode = %.1f km, want half the circumference", km)
+	}
+}
+
+func TestGeoHash(t *testing.T) {
+	// The reference example from the geohash specification.
+	hash := EncodeGeoHash(Point{Lat: 57.64911, Lon: 10.40744}, 11)
+	if hash != "u4pruydqqvj" {
+		t.Errorf("EncodeGeoHash = %s, want u4pruydqqvj", hash)
+	}
+	box, err := DecodeGeoHash("U4PRUYDQQVJ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !box.Contains(Point{Lat: 57.64911, Lon: 10.40744}) {
+		t.Errorf("box %+v does not contain the encoded point", box)
+	}
+	for _, p := range []Point{london, newYork, paris, {Lat: -33.8688, Lon: 151.2093}} {
+		box, err := DecodeGeoHash(EncodeGeoHash(p, 9))
+		if err != nil || box.Center().DistanceKm(p) > 0.01 {
+			t.Errorf("round trip of %+v = %+v, %v", p, box.Center(), err)
+		}
+	}
+
+	for _, hash := range []string{"", "u4pa", "u4p!"} {
+		_, err := DecodeGeoHash(hash)
+		if !errors.Is(err, ErrInvalidGeoHash) {
+			t.Errorf("DecodeGeoHash(%q) err = %v, want ErrInvalidGeoHash", hash, err)
+		}
+	}
+}
+
+func located(p Point, categories ...string) synthient.IP {
+	var ip synthient.IP
+	ip.Location.Latitude = p.Lat
+	ip.Location.Longitude = p.Lon
+	ip.Intelligence.Categories = categories
+	return ip
+}
+
+func TestLocateFallsBackToGeoHash(t *testing.T) {
+	var ip synthient.IP
+	ip.Location.GeoHash = EncodeGeoHash(paris, 8)
+	p, ok := Locate(ip)
+	if !ok || p.DistanceKm(paris) > 0.1 {
+		t.Errorf("Locate = %+v, %v", p, ok)
+	}
+	_, ok = Locate(synthient.IP{})
+	if ok {
+		t.Error("Locate found a location in an empty lookup")
+	}
+}
+
+func TestImpossibleTravel(t *testing.T) {
+	start := time.Date(2026, 3, 10, 8, 0, 0, 0, time.UTC)
+	logins := []Login{
+		{Time: start.Add(2 * time.Hour), IP: located(newYork)},
+		{Time: start, IP: located(london)},
+		{Time: start.Add(time.Hour), IP: located(paris)},
+		// A VPN exit in New York right after London is ignored.
+		{Time: start.Add(10 * time.Minute), IP: located(newYork, "VPN")},
+		{Time: start.Add(30 * time.Minute), IP: synthient.IP{}},
+	}
+
+	trips := Travel(logins, nil)
+	if len(trips) != 2 {
+		t.Fatalf("Travel returned %d trips, want 2: %+v", len(trips), trips)
+	}
+	if trips[0].Impossible {
+		t.Errorf("London-Paris in an hour flagged: %+v", trips[0])
+	}
+	if !trips[1].Impossible || trips[1].Elapsed != time.Hour {
+		t.Errorf("Paris-New York in an hour not flagged: %+v", trips[1])
+	}
+
+	impossible := ImpossibleTravel(logins, &TravelOptions{IncludeAnonymized: true})
+	if len(impossible) != 3 {
+		t.Errorf("with anonymized logins got %d impossible trips, want 3", len(impossible))
+	}
+
+	simultaneous := Travel([]Login{{Time: start, IP: located(london)}, {Time: start, IP: located(paris)}}, nil)
+	if !math.IsInf(simultaneous[0].SpeedKmh, 1) || !simultaneous[0].Impossible {
+		t.Errorf("simultaneous logins = %+v", simultaneous[0])
+	}
+	nearby := Travel([]Login{{Time: start, IP: located(london)}, {Time: start, IP: located(Point{Lat: 51.75, Lon: -1.25})}}, nil)
+	if nearby[0].Impossible {
+		t.Errorf("logins %.0f km apart flagged", nearby[0].DistanceKm)
+	}
+}
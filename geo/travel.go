@@ -0,0 +1,115 @@
+package geo
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/synthient/go-synthient/v2"
+)
+
+// DefaultMaxSpeedKmh is the fastest plausible travel speed, roughly that of a
+// commercial airliner, used unless TravelOptions.MaxSpeedKmh says otherwise.
+const DefaultMaxSpeedKmh = 1000
+
+// DefaultMinDistanceKm is the distance below which a trip is never impossible, used
+// unless TravelOptions.MinDistanceKm says otherwise. IP geolocation is often only
+// accurate to a region, so nearby locations are not evidence of travel.
+const DefaultMinDistanceKm = 300
+
+// Login is a sign-in, or any other event, by one user from a looked-up IP.
+type Login struct {
+	Time time.Time
+	IP   synthient.IP
+}
+
+// TravelOptions configures Travel and ImpossibleTravel.
+type TravelOptions struct {
+	// MaxSpeedKmh is the speed above which a trip is impossible. Defaults to
+	// DefaultMaxSpeedKmh.
+	MaxSpeedKmh float64
+	// MinDistanceKm is the distance below which a trip is never impossible. Defaults
+	// to DefaultMinDistanceKm.
+	MinDistanceKm float64
+	// IncludeAnonymized keeps logins from anonymized IPs as trip endpoints. By default
+	// they are skipped, since an exit node's location says nothing about where the
+	// user is.
+	IncludeAnonymized bool
+}
+
+// Trip is the movement implied by two consecutive located logins.
+type Trip struct {
+	From, To   Login
+	DistanceKm float64
+	Elapsed    time.Duration
+	// SpeedKmh is DistanceKm over Elapsed. It is +Inf when both logins happened at
+	// the same instant from different places.
+	SpeedKmh float64
+	// Impossible reports whether SpeedKmh exceeds the maximum speed over at least
+	// the minimum distance.
+	Impossible bool
+}
+
+// Anonymized reports whether ip's location is unlikely to be the user's own: a proxy
+// (of any kind), VPN or Tor exit, or a hosting network.
+func Anonymized(ip synthient.IP) bool {
+	return ip.IsProxy() || ip.IsVPN() || ip.IsTor() || ip.IsHosting()
+}
+
+// Travel returns the trips between consecutive logins, in time order. logins need not
+// be sorted. Logins without a usable location are skipped, as are logins from
+// Anonymized IPs unless travel.IncludeAnonymized is set; a trip then spans from the
+// last kept login to the next one. A nil travel uses the defaults.
+func Travel(logins []Login, travel *TravelOptions) []Trip {
+	options := travelDefaults(travel)
+	sorted := slices.Clone(logins)
+	slices.SortStableFunc(sorted, func(a, b Login) int { return a.Time.Compare(b.Time) })
+
+	var trips []Trip
+	var prev Login
+	var prevPoint Point
+	havePrev := false
+	for _, login := range sorted {
+		if !options.IncludeAnonymized && Anonymized(login.IP) {
+			continue
+		}
+		point, ok := Locate(login.IP)
+		if !ok {
+			continue
+		}
+		if havePrev {
+			trips = append(trips, newTrip(prev, login, prevPoint.DistanceKm(point), options))
+		}
+		prev, prevPoint, havePrev = login, point, true
+	}
+	return trips
+}
+
+// ImpossibleTravel returns the trips from Travel that are impossible.
+func ImpossibleTravel(logins []Login, travel *TravelOptions) []Trip {
+	return slices.DeleteFunc(Travel(logins, travel), func(trip Trip) bool { return !trip.Impossible })
+}
+
+func newTrip(from, to Login, km float64, options TravelOptions) Trip {
+	trip := Trip{From: from, To: to, DistanceKm: km, Elapsed: to.Time.Sub(from.Time)}
+	hours := trip.Elapsed.Hours()
+	switch {
+	case hours > 0:
+		trip.SpeedKmh = km / hours
+	case km > 0:
+		trip.SpeedKmh = math.Inf(1)
+	}
+	trip.Impossible = km >= options.MinDistanceKm && trip.SpeedKmh > options.MaxSpeedKmh
+	return trip
+}
+
+func travelDefaults(travel *TravelOptions) TravelOptions {
+	var options TravelOptions
+	if travel != nil {
+		options = *travel
+	}
+	options.MaxSpeedKmh = cmp.Or(options.MaxSpeedKmh, DefaultMaxSpeedKmh)
+	options.MinDistanceKm = cmp.Or(options.MinDistanceKm, DefaultMinDistanceKm)
+	return options
+}
@@ -51,10 +51,11 @@ type GRPCSchemaResult struct {
 
 // GRPCSchema uses gRPC server reflection to fetch protobuf file descriptors from a
 // Synthient gRPC endpoint. If options is nil or options.Endpoint is empty, it connects
-// to DefaultGRPCEndpoint. If options.Symbols is empty, all services exposed by the
-// server are fetched.
+// to client.GRPCEndpoint, falling back to DefaultGRPCEndpoint. If options.Symbols is
+// empty, all services exposed by the server are fetched.
 //
-// The client's token is forwarded as the x-api-key metadata header when non-empty.
+// The client's token (from client.Tokens when set) is forwarded as the x-api-key
+// metadata header when non-empty.
 //
 // Example (all services):
 //
@@ -76,7 +77,11 @@ func (client *Client) GRPCSchema(ctx context.Context, options *GRPCSchemaOptions
 		options = &GRPCSchemaOptions{}
 	}
 
-	endpoint, host, err := NormalizeGRPCEndpoint(options.Endpoint)
+	rawEndpoint := options.Endpoint
+	if strings.TrimSpace(rawEndpoint) == "" {
+		rawEndpoint = client.GRPCEndpoint
+	}
+	endpoint, host, err := NormalizeGRPCEndpoint(rawEndpoint)
 	if err != nil {
 		return GRPCSchemaResult{}, err
 	}
@@ -98,8 +103,12 @@ func (client *Client) GRPCSchema(ctx context.Context, options *GRPCSchemaOptions
 	}
 	defer func() { _ = conn.Close() }()
 
-	if strings.TrimSpace(client.Token) != "" {
-		ctx = metadata.AppendToOutgoingContext(ctx, "x-api-key", client.Token)
+	token, err := client.token(ctx)
+	if err != nil && !errors.Is(err, ErrNoToken) {
+		return GRPCSchemaResult{}, err
+	}
+	if token != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "x-api-key", token)
 	}
 
 	rc := reflectpb.NewServerReflectionClient(conn)
@@ -109,7 +109,10 @@ type HeliosTLSEvent struct {
 func (client *Client) StreamHeliosTLS(
 	requestOptions *RequestOptions,
 ) iter.Seq2[HeliosTLSEvent, error] {
-	return streamFeed[HeliosTLSEvent](client, requestOptions, "feeds", "helio", "https", "stream")
+	return streamFeed[HeliosTLSEvent](
+		client, requestOptions, "StreamHeliosTLS", "honeypot_https",
+		"feeds", "helio", "https", "stream",
+	)
 }
 
 // // HeliosDNSEvent is a single DNS resolution observation delivered by the Helios DNS sensor
@@ -153,7 +156,10 @@ func (client *Client) StreamHeliosTLS(
 // //	}
 // func (client *Client) StreamHeliosADB(requestOptions *RequestOptions) iter.Seq2[HeliosADBEvent,
 // error] {
-// 	return streamFeed[HeliosADBEvent](client, requestOptions, "feeds", "helio", "adb", "stream")
+// 	return streamFeed[HeliosADBEvent](
+// 		client, requestOptions, "StreamHeliosADB", "honeypot_adb",
+// 		"feeds", "helio", "adb", "stream",
+// 	)
 // }
 
 // // StreamHeliosDNS connects to the real-time Helios DNS capture stream and returns an
@@ -173,7 +179,10 @@ func (client *Client) StreamHeliosTLS(
 // //	}
 // func (client *Client) StreamHeliosDNS(requestOptions *RequestOptions) iter.Seq2[HeliosDNSEvent,
 // error] {
-// 	return streamFeed[HeliosDNSEvent](client, requestOptions, "feeds", "helio", "dns", "stream")
+// 	return streamFeed[HeliosDNSEvent](
+// 		client, requestOptions, "StreamHeliosDNS", "honeypot_dns",
+// 		"feeds", "helio", "dns", "stream",
+// 	)
 // }
 
 // StreamHeliosHTTP connects to the real-time Helios HTTP capture stream and returns an
@@ -193,7 +202,10 @@ func (client *Client) StreamHeliosTLS(
 func (client *Client) StreamHeliosHTTP(
 	requestOptions *RequestOptions,
 ) iter.Seq2[HeliosHTTPEvent, error] {
-	return streamFeed[HeliosHTTPEvent](client, requestOptions, "feeds", "helio", "http", "stream")
+	return streamFeed[HeliosHTTPEvent](
+		client, requestOptions, "StreamHeliosHTTP", "honeypot_http",
+		"feeds", "helio", "http", "stream",
+	)
 }
 
 // DownloadHeliosHTTP downloads a Helios HTTP capture Parquet snapshot. If filename is
@@ -222,7 +234,8 @@ func (client *Client) DownloadHeliosHTTP(
 	filename string,
 	requestOptions *RequestOptions,
 ) (io.ReadCloser, error) {
-	return downloadFeed(client, requestOptions, date, hour, filename, "feeds", "helio", "http")
+	call := &Call{Operation: "DownloadHeliosHTTP", Stream: "honeypot_http"}
+	return downloadFeed(client, requestOptions, call, date, hour, filename, "feeds", "helio", "http")
 }
 
 // DownloadHeliosTLS downloads a Helios TLS capture Parquet snapshot. If filename is
@@ -251,5 +264,6 @@ func (client *Client) DownloadHeliosTLS(
 	filename string,
 	requestOptions *RequestOptions,
 ) (io.ReadCloser, error) {
-	return downloadFeed(client, requestOptions, date, hour, filename, "feeds", "helio", "https")
+	call := &Call{Operation: "DownloadHeliosTLS", Stream: "honeypot_https"}
+	return downloadFeed(client, requestOptions, call, date, hour, filename, "feeds", "helio", "https")
 }
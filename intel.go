@@ -0,0 +1,180 @@
+package synthient
+
+import (
+	"slices"
+	"strings"
+)
+
+// The NetworkType, Behavior and Category constants are maintained by hand in this
+// package, spelled as the API spells them: network types in lower case, behaviors and
+// categories in upper snake case. They are not generated from an API schema, so a
+// response may carry values that have no constant; those are kept as received, and
+// Known tells them apart.
+
+// NetworkType classifies the network an IP belongs to (IP.NetworkType). Values the
+// SDK does not know are kept as received; use Known to tell them apart.
+type NetworkType string
+
+// Known network types.
+const (
+	NetworkISP        NetworkType = "isp"
+	NetworkMobile     NetworkType = "mobile"
+	NetworkHosting    NetworkType = "hosting"
+	NetworkBusiness   NetworkType = "business"
+	NetworkEducation  NetworkType = "education"
+	NetworkGovernment NetworkType = "government"
+)
+
+var knownNetworkTypes = []NetworkType{
+	NetworkISP, NetworkMobile, NetworkHosting, NetworkBusiness, NetworkEducation, NetworkGovernment,
+}
+
+// Known reports whether t is one of the NetworkType constants.
+func (t NetworkType) Known() bool { return known(knownNetworkTypes, t) }
+
+// Is reports whether t equals other, ignoring case and treating '-' and ' ' like '_'.
+func (t NetworkType) Is(other NetworkType) bool { return sameValue(string(t), string(other)) }
+
+// Behavior is an activity observed from an IP (IP.Behaviors). Values the
+// SDK does not know are kept as received; use Known to tell them apart.
+type Behavior string
+
+// Known behaviors.
+const (
+	BehaviorScanning           Behavior = "SCANNING"
+	BehaviorBruteForce         Behavior = "BRUTE_FORCE"
+	BehaviorCredentialStuffing Behavior = "CREDENTIAL_STUFFING"
+	BehaviorAccountTakeover    Behavior = "ACCOUNT_TAKEOVER"
+	BehaviorScraping           Behavior = "SCRAPING"
+	BehaviorSpam               Behavior = "SPAM"
+	BehaviorClickFraud         Behavior = "CLICK_FRAUD"
+)
+
+var knownBehaviors = []Behavior{
+	BehaviorScanning, BehaviorBruteForce, BehaviorCredentialStuffing, BehaviorAccountTakeover,
+	BehaviorScraping, BehaviorSpam, BehaviorClickFraud,
+}
+
+// Known reports whether b is one of the Behavior constants.
+func (b Behavior) Known() bool { return known(knownBehaviors, b) }
+
+// Is reports whether b equals other, ignoring case and treating '-' and ' ' like '_'.
+func (b Behavior) Is(other Behavior) bool { return sameValue(string(b), string(other)) }
+
+// Category classifies what an IP is used for (IP.Categories and
+// IPProvider.Category). Values the SDK does not know are kept as received; use Known
+// to tell them apart.
+type Category string
+
+// Known categories.
+const (
+	CategoryResidentialProxy Category = "RESIDENTIAL_PROXY"
+	CategoryMobileProxy      Category = "MOBILE_PROXY"
+	CategoryDatacenterProxy  Category = "DATACENTER_PROXY"
+	CategoryProxy            Category = "PROXY"
+	CategoryVPN              Category = "VPN"
+	CategoryTor              Category = "TOR"
+	CategoryRelay            Category = "RELAY"
+	CategoryHosting          Category = "HOSTING"
+)
+
+var knownCategories = []Category{
+	CategoryResidentialProxy, CategoryMobileProxy, CategoryDatacenterProxy, CategoryProxy,
+	CategoryVPN, CategoryTor, CategoryRelay, CategoryHosting,
+}
+
+// Known reports whether c is one of the Category constants.
+func (c Category) Known() bool { return known(knownCategories, c) }
+
+// Is reports whether c equals other, ignoring case and treating '-' and ' ' like '_'.
+func (c Category) Is(other Category) bool { return sameValue(string(c), string(other)) }
+
+// IsProxy reports whether c is any kind of proxy.
+func (c Category) IsProxy() bool {
+	return c.Is(CategoryProxy) || c.Is(CategoryResidentialProxy) ||
+		c.Is(CategoryMobileProxy) || c.Is(CategoryDatacenterProxy)
+}
+
+// NetworkType returns ip.Network.Type as a NetworkType.
+func (ip IP) NetworkType() NetworkType { return NetworkType(ip.Network.Type) }
+
+// Behaviors returns ip.Intelligence.Behavior as Behavior values.
+func (ip IP) Behaviors() []Behavior { return convert[Behavior](ip.Intelligence.Behavior) }
+
+// Categories returns ip.Intelligence.Categories as Category values.
+func (ip IP) Categories() []Category { return convert[Category](ip.Intelligence.Categories) }
+
+// Category returns p.Type as a Category.
+func (p IPProvider) Category() Category { return Category(p.Type) }
+
+// HasCategory reports whether ip lists c among its categories or provider types.
+func (ip IP) HasCategory(c Category) bool {
+	return ip.hasCategory(func(other Category) bool { return other.Is(c) })
+}
+
+// HasBehavior reports whether ip lists b among its observed behaviors.
+func (ip IP) HasBehavior(b Behavior) bool {
+	return slices.ContainsFunc(ip.Intelligence.Behavior, func(other string) bool { return b.Is(Behavior(other)) })
+}
+
+// IsProxy reports whether ip is categorized, or seen by a provider, as any kind of
+// proxy.
+func (ip IP) IsProxy() bool { return ip.hasCategory(Category.IsProxy) }
+
+// IsResidentialProxy reports whether ip is a residential or mobile proxy exit.
+func (ip IP) IsResidentialProxy() bool {
+	return ip.HasCategory(CategoryResidentialProxy) || ip.HasCategory(CategoryMobileProxy)
+}
+
+// IsVPN reports whether ip is a VPN endpoint.
+func (ip IP) IsVPN() bool { return ip.HasCategory(CategoryVPN) }
+
+// IsTor reports whether ip is a Tor node.
+func (ip IP) IsTor() bool { return ip.HasCategory(CategoryTor) }
+
+// IsHosting reports whether ip belongs to a hosting or data center network.
+func (ip IP) IsHosting() bool {
+	return ip.NetworkType().Is(NetworkHosting) || ip.HasCategory(CategoryHosting) ||
+		ip.HasCategory(CategoryDatacenterProxy)
+}
+
+// IsResidential reports whether ip is on a consumer ISP network. It says nothing about
+// proxy use; combine it with IsResidentialProxy for that.
+func (ip IP) IsResidential() bool { return ip.NetworkType().Is(NetworkISP) }
+
+func (ip IP) hasCategory(match func(Category) bool) bool {
+	for _, c := range ip.Intelligence.Categories {
+		if match(Category(c)) {
+			return true
+		}
+	}
+	for _, provider := range ip.Intelligence.Providers {
+		if match(provider.Category()) {
+			return true
+		}
+	}
+	return false
+}
+
+// convert returns values as T, or nil for an empty slice.
+func convert[T ~string](values []string) []T {
+	if len(values) == 0 {
+		return nil
+	}
+	converted := make([]T, len(values))
+	for i, v := range values {
+		converted[i] = T(v)
+	}
+	return converted
+}
+
+func known[T ~string](values []T, value T) bool {
+	return slices.ContainsFunc(values, func(v T) bool { return sameValue(string(v), string(value)) })
+}
+
+var valueReplacer = strings.NewReplacer("-", "_", " ", "_")
+
+// sameValue compares API enum values leniently, since their spelling has varied.
+func sameValue(a, b string) bool {
+	return strings.EqualFold(valueReplacer.Replace(a), valueReplacer.Replace(b))
+}
@@ -0,0 +1,60 @@
+package synthient
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestIPPredicates(t *testing.T) {
+	var ip IP
+	err := json.Unmarshal([]byte(`{
+		"ip": "213.149.183.127",
+		"network": {"type": "ISP"},
+		"intelligence": {
+			"behavior": ["credential-stuffing", "NEW_BEHAVIOR"],
+			"categories": ["residential_proxy"],
+			"providers": [{"provider": "acme", "type": "VPN"}, {"provider": "other", "type": "SOMETHING_NEW"}]
+		}
+	}`), &ip)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checks := map[string]bool{
+		"IsProxy":            ip.IsProxy(),
+		"IsResidentialProxy": ip.IsResidentialProxy(),
+		"IsVPN":              ip.IsVPN(),
+		"IsResidential":      ip.IsResidential(),
+		"HasBehavior":        ip.HasBehavior(BehaviorCredentialStuffing),
+		"!IsTor":             !ip.IsTor(),
+		"!IsHosting":         !ip.IsHosting(),
+	}
+	for name, ok := range checks {
+		if !ok {
+			t.Errorf("%s failed for %+v", name, ip.Intelligence)
+		}
+	}
+
+	if behaviors := ip.Behaviors(); behaviors[1] != "NEW_BEHAVIOR" || behaviors[1].Known() {
+		t.Errorf("unknown behavior = %q, known=%v", behaviors[1], behaviors[1].Known())
+	}
+	if !ip.NetworkType().Known() || ip.Intelligence.Providers[1].Category().Known() {
+		t.Error("Known() misclassified network or provider type")
+	}
+	out, err := json.Marshal(ip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var again IP
+	_ = json.Unmarshal(out, &again)
+	if again.Intelligence.Providers[1].Type != "SOMETHING_NEW" {
+		t.Errorf("unknown provider type lost in round trip: %s", out)
+	}
+	if categories := ip.Categories(); len(categories) != 1 || !categories[0].Is(CategoryResidentialProxy) {
+		t.Errorf("Categories() = %v", categories)
+	}
+	if strings.Join(ip.Intelligence.Behavior, ",") != "credential-stuffing,NEW_BEHAVIOR" {
+		t.Errorf("Behavior = %v", ip.Intelligence.Behavior)
+	}
+}
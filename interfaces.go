@@ -0,0 +1,63 @@
+package synthient
+
+import (
+	"io"
+	"iter"
+)
+
+// The interfaces below describe the Client surface in small pieces, so code can depend
+// on the behavior it uses rather than on *Client and its transport. *Client satisfies
+// all of them; synthienttest.Fake is an in-memory implementation for tests.
+
+// IPLookuper looks up IP address intelligence.
+type IPLookuper interface {
+	GetIP(ip string, options *RequestOptions) (IP, error)
+	GetIPs(ips []string, options *RequestOptions) ([]IP, error)
+}
+
+// DomainLookuper looks up domain intelligence.
+type DomainLookuper interface {
+	GetDomain(domain string, options *RequestOptions) (Domain, error)
+}
+
+// AccountGetter returns the authenticated account.
+type AccountGetter interface {
+	GetAccount(options *RequestOptions) (Account, error)
+}
+
+// FeedStreamer connects to the real-time feed streams.
+type FeedStreamer interface {
+	StreamProxy(requestOptions *RequestOptions) iter.Seq2[ProxyEvent, error]
+	StreamAnonymizer(requestOptions *RequestOptions) iter.Seq2[AnonymizerEvent, error]
+	StreamTorrent(requestOptions *RequestOptions) iter.Seq2[TorrentEvent, error]
+	StreamHeliosHTTP(requestOptions *RequestOptions) iter.Seq2[HeliosHTTPEvent, error]
+	StreamHeliosTLS(requestOptions *RequestOptions) iter.Seq2[HeliosTLSEvent, error]
+}
+
+// SnapshotDownloader lists and downloads Parquet feed snapshots.
+type SnapshotDownloader interface {
+	FeedSnapshots(
+		stream string,
+		options *FeedSnapshotsOptions,
+		requestOptions *RequestOptions,
+	) (FeedSnapshotsPage, error)
+	FeedSnapshotMeta(stream string, date string, requestOptions *RequestOptions) (FeedSnapshotMeta, error)
+	DownloadFeedSnapshot(
+		stream string,
+		date string,
+		hour *int,
+		filename string,
+		requestOptions *RequestOptions,
+	) (io.ReadCloser, error)
+}
+
+// API is the combined REST surface of Client.
+type API interface {
+	IPLookuper
+	DomainLookuper
+	AccountGetter
+	FeedStreamer
+	SnapshotDownloader
+}
+
+var _ API = (*Client)(nil)
@@ -0,0 +1,92 @@
+// Package clientip finds the original client address of a request that may have
+// passed through reverse proxies, from the Forwarded, X-Forwarded-For and X-Real-IP
+// headers. It is shared by synthienthttp and synthientgrpc.
+package clientip
+
+import (
+	"net/netip"
+	"slices"
+	"strings"
+)
+
+// DefaultHeaders are the forwarding headers consulted, in order, by default.
+var DefaultHeaders = []string{"Forwarded", "X-Forwarded-For", "X-Real-IP"}
+
+// Resolve returns the client address of a request received from remote, and whether
+// remote is a trusted proxy. Forwarding headers are only believed when it is. The
+// first of headers that is present is used: its hops are walked from the nearest,
+// skipping trusted proxies, and the first untrusted hop is the client. A hop that is
+// not an IP address (such as an obfuscated Forwarded identifier) hides the client, so
+// reaching one returns the zero Addr. If every hop is trusted the farthest one is
+// returned. get returns the values of a header; names are compared as the caller's
+// header type does.
+func Resolve(remote netip.Addr, trusted []netip.Prefix, headers []string, get func(name string) []string) (client netip.Addr, proxied bool) {
+	remote = remote.Unmap()
+	if !isTrusted(trusted, remote) {
+		return remote, false
+	}
+	for _, name := range headers {
+		hops := Parse(name, get(name))
+		if len(hops) == 0 {
+			continue
+		}
+		for i := len(hops) - 1; i >= 0; i-- {
+			if i == 0 || !hops[i].IsValid() || !isTrusted(trusted, hops[i]) {
+				return hops[i], true
+			}
+		}
+	}
+	return remote, true
+}
+
+// Parse returns the hops listed in the values of a forwarding header, farthest first.
+// A hop that is not an IP address, or a Forwarded element without a for= parameter, is
+// returned as the zero Addr.
+func Parse(name string, values []string) []netip.Addr {
+	forwarded := strings.EqualFold(name, "Forwarded")
+	var hops []netip.Addr
+	for _, value := range values {
+		for element := range strings.SplitSeq(value, ",") {
+			element = strings.TrimSpace(element)
+			if element == "" {
+				continue
+			}
+			if forwarded {
+				element = forwardedFor(element)
+			}
+			addr, _ := ParseHost(element)
+			hops = append(hops, addr)
+		}
+	}
+	return hops
+}
+
+// forwardedFor returns the for= parameter of a Forwarded element (RFC 7239), or "".
+func forwardedFor(element string) string {
+	for pair := range strings.SplitSeq(element, ";") {
+		key, value, found := strings.Cut(strings.TrimSpace(pair), "=")
+		if found && strings.EqualFold(strings.TrimSpace(key), "for") {
+			return strings.Trim(strings.TrimSpace(value), `"`)
+		}
+	}
+	return ""
+}
+
+// ParseHost parses an address with an optional port, in any of the forms "1.2.3.4",
+// "1.2.3.4:80", "2001:db8::1" and "[2001:db8::1]:80". IPv4-mapped addresses are
+// unmapped.
+func ParseHost(host string) (netip.Addr, bool) {
+	host = strings.TrimSpace(host)
+	if addrPort, err := netip.ParseAddrPort(host); err == nil {
+		return addrPort.Addr().Unmap(), true
+	}
+	addr, err := netip.ParseAddr(strings.TrimSuffix(strings.TrimPrefix(host, "["), "]"))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+func isTrusted(trusted []netip.Prefix, addr netip.Addr) bool {
+	return slices.ContainsFunc(trusted, func(prefix netip.Prefix) bool { return prefix.Contains(addr) })
+}
@@ -0,0 +1,87 @@
+package clientip
+
+import (
+	"net/http"
+	"net/netip"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("2001:db8::/32")}
+	proxy := netip.MustParseAddr("10.0.0.2")
+
+	tests := []struct {
+		name    string
+		remote  netip.Addr
+		header  http.Header
+		want    string
+		proxied bool
+	}{
+		{
+			name:   "untrusted remote ignores headers",
+			remote: netip.MustParseAddr("213.149.183.77"),
+			header: http.Header{"X-Forwarded-For": {"8.8.8.8"}},
+			want:   "213.149.183.77",
+		},
+		{
+			name:    "rightmost untrusted hop",
+			remote:  proxy,
+			header:  http.Header{"X-Forwarded-For": {"1.1.1.1, 213.149.183.127", "10.0.0.9"}},
+			want:    "213.149.183.127",
+			proxied: true,
+		},
+		{
+			name:    "junk beyond the client",
+			remote:  proxy,
+			header:  http.Header{"X-Forwarded-For": {"junk, 203.0.113.7"}},
+			want:    "203.0.113.7",
+			proxied: true,
+		},
+		{
+			name:    "junk hides the client",
+			remote:  proxy,
+			header:  http.Header{"X-Forwarded-For": {"203.0.113.7, junk, 10.0.0.9"}},
+			want:    "invalid IP",
+			proxied: true,
+		},
+		{
+			name:    "all hops trusted",
+			remote:  proxy,
+			header:  http.Header{"X-Forwarded-For": {"10.1.1.1, 10.0.0.9"}},
+			want:    "10.1.1.1",
+			proxied: true,
+		},
+		{
+			name:   "forwarded wins",
+			remote: proxy,
+			header: http.Header{
+				"Forwarded":       {`for=198.51.100.1;proto=https, for="[2606:4700::11]:4711";by=10.0.0.2`},
+				"X-Forwarded-For": {"8.8.8.8"},
+			},
+			want:    "2606:4700::11",
+			proxied: true,
+		},
+		{
+			name:    "obfuscated forwarded hides the client",
+			remote:  proxy,
+			header:  http.Header{"Forwarded": {"for=_hidden"}, "X-Real-Ip": {"213.149.183.127:5000"}},
+			want:    "invalid IP",
+			proxied: true,
+		},
+		{
+			name:    "no headers",
+			remote:  netip.MustParseAddr("::ffff:10.0.0.2"),
+			header:  http.Header{},
+			want:    "10.0.0.2",
+			proxied: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, proxied := Resolve(test.remote, trusted, DefaultHeaders, test.header.Values)
+			if got.String() != test.want || proxied != test.proxied {
+				t.Errorf("Resolve = %s, %v, want %s, %v", got, proxied, test.want, test.proxied)
+			}
+		})
+	}
+}
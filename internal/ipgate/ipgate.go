@@ -0,0 +1,152 @@
+// Package ipgate holds the gating shared by synthienthttp and synthientgrpc:
+// attributing a request to its client address, looking the address up, applying the
+// failure modes and carrying the Result in the request context. The public packages
+// only add their transport.
+package ipgate
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"time"
+
+	"github.com/synthient/go-synthient/v2"
+	"github.com/synthient/go-synthient/v2/internal/clientip"
+	"github.com/synthient/go-synthient/v2/policy"
+)
+
+// FailureMode chooses what happens to a request whose lookup failed.
+type FailureMode int
+
+const (
+	// FailOpen lets the request through without a lookup result.
+	FailOpen FailureMode = iota
+	// FailClosed rejects the request.
+	FailClosed
+)
+
+// Result is what a gate learned about a request.
+type Result struct {
+	// ClientAddr is the client address the request was attributed to.
+	ClientAddr netip.Addr
+	// IP is the lookup of ClientAddr. It is the zero IP when Err is set.
+	IP synthient.IP
+	// Err is the lookup error, if any. Addresses that cannot be looked up, such as
+	// private ones, fail with synthient.ErrInvalidAddr. Such requests are always let
+	// through when they come straight from the client; behind a trusted proxy, where
+	// the forwarding headers name no usable client address, they are handled like any
+	// other failed lookup.
+	Err error
+	// Decision is the decision taken for the request.
+	Decision policy.Decision
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying result.
+func NewContext(ctx context.Context, result *Result) context.Context {
+	return context.WithValue(ctx, contextKey{}, result)
+}
+
+// FromContext returns the Result stored in ctx.
+func FromContext(ctx context.Context) (*Result, bool) {
+	result, ok := ctx.Value(contextKey{}).(*Result)
+	return result, ok
+}
+
+// IPFromContext returns the lookup stored in ctx. ok is false when there is none or
+// the lookup failed.
+func IPFromContext(ctx context.Context) (ip synthient.IP, ok bool) {
+	result, ok := FromContext(ctx)
+	if !ok || result.Err != nil {
+		return synthient.IP{}, false
+	}
+	return result.IP, true
+}
+
+// BlockAbove blocks IPs with a RiskScore of at least score and allows the rest.
+func BlockAbove(score int) func(ip synthient.IP) policy.Decision {
+	return func(ip synthient.IP) policy.Decision {
+		if ip.Intelligence.RiskScore >= score {
+			return policy.Block
+		}
+		return policy.Allow
+	}
+}
+
+// Evaluate decides with a risk policy.
+func Evaluate(evaluator policy.Evaluator) func(ip synthient.IP) policy.Decision {
+	return func(ip synthient.IP) policy.Decision {
+		return evaluator.Evaluate(ip).Decision
+	}
+}
+
+// Gate looks up the clients of requests.
+type Gate struct {
+	Lookups        synthient.IPLookuper
+	TrustedProxies []netip.Prefix
+	Headers        []string
+	Timeout        time.Duration
+	OnAPIError     FailureMode
+	OnNoCredits    FailureMode
+}
+
+// Check attributes a request received from remote to its client, following the
+// forwarding headers get returns when remote is a trusted proxy, and looks the client
+// up. decide, if not nil, decides a successful lookup. onError, if not nil, is called
+// with every failed lookup; a failed lookup is blocked when its failure mode is
+// FailClosed and allowed otherwise.
+func (g *Gate) Check(
+	ctx context.Context,
+	remote netip.Addr,
+	get func(name string) []string,
+	decide func(ip synthient.IP) policy.Decision,
+	onError func(err error),
+) *Result {
+	addr, proxied := clientip.Resolve(remote, g.TrustedProxies, g.Headers, get)
+	result := &Result{ClientAddr: addr, Decision: policy.Allow}
+
+	err := synthient.ValidateAddr(addr)
+	if err != nil && !proxied {
+		// a private client talking to the server directly, such as a health check
+		result.Err = err
+		return result
+	}
+	if err == nil {
+		result.IP, err = g.getIP(ctx, addr)
+	}
+	if err != nil {
+		result.Err = err
+		if onError != nil {
+			onError(err)
+		}
+		if g.failureMode(err) == FailClosed {
+			result.Decision = policy.Block
+		}
+		return result
+	}
+	if decide != nil {
+		result.Decision = decide(result.IP)
+	}
+	return result
+}
+
+func (g *Gate) getIP(ctx context.Context, addr netip.Addr) (synthient.IP, error) {
+	if g.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, g.Timeout)
+		defer cancel()
+	}
+	ip, err := g.Lookups.GetIP(addr.String(), &synthient.RequestOptions{Context: ctx})
+	if err != nil {
+		return synthient.IP{}, err
+	}
+	return ip, nil
+}
+
+func (g *Gate) failureMode(err error) FailureMode {
+	if errors.Is(err, synthient.ErrPaymentRequired) || errors.Is(err, synthient.ErrQuotaExhausted) {
+		return g.OnNoCredits
+	}
+	return g.OnAPIError
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/netip"
 	"net/url"
 )
 
@@ -22,8 +23,16 @@ import (
 //
 // Commonly used fields include IP.IP, Network.Asn/Network.Isp, Location.Country,
 // and IPData.IPRisk.
+//
+// Addr is IP.IP parsed when the result is decoded; it is not part of the JSON payload.
+//
+// NetworkType, Behaviors, Categories and IPProvider.Category return Network.Type,
+// Intelligence.Behavior, Intelligence.Categories and Providers[].Type as the
+// NetworkType, Behavior and Category types. The IsProxy, IsVPN, IsTor, IsHosting and
+// IsResidential methods answer the common questions about them.
 type IP struct {
-	IP      string `json:"ip"`
+	IP      string     `json:"ip"`
+	Addr    netip.Addr `json:"-"`
 	Network struct {
 		Asn        int    `json:"asn"`
 		Isp        string `json:"isp"`
@@ -50,14 +59,18 @@ type IP struct {
 			OS      string `json:"os"`
 			Version string `json:"version"`
 		} `json:"devices"`
-		Providers []struct {
-			Provider string `json:"provider"`
-			Type     string `json:"type"`
-			LastSeen int64  `json:"last_seen"`
-		} `json:"providers"`
+		Providers []IPProvider `json:"providers"`
 	} `json:"intelligence"`
 }
 
+// IPProvider is a proxy or anonymizer provider that has been seen using an IP.
+// LastSeenTime returns LastSeen as a time.Time, and Category returns Type as a Category.
+type IPProvider struct {
+	Provider string `json:"provider"`
+	Type     string `json:"type"`
+	LastSeen int64  `json:"last_seen"`
+}
+
 // GetIP looks up enrichment data for a single IP address.
 //
 // It performs an HTTP GET request to the Synthient IP lookup endpoint and
@@ -73,7 +86,30 @@ type IP struct {
 //		log.Fatal(err)
 //	}
 //	fmt.Printf("%+v\n", info)
+//
+// When client.Cache is set, a fresh cached result is returned without a request.
+// Concurrent calls for the same address share one request (see WithoutCoalescing).
+//
+// ip is validated and canonicalized with ParseAddr first; an *AddrError is returned
+// without making a request when it is rejected.
 func (client *Client) GetIP(ip string, options *RequestOptions) (IP, error) {
+	addr, err := ParseAddr(ip)
+	if err != nil {
+		return IP{}, err
+	}
+	ip = addr.String()
+	lookup := func() (IP, error) {
+		return coalesce(client, ipCacheKey(ip), options, func(options *RequestOptions) (IP, error) {
+			return client.getIP(ip, options)
+		})
+	}
+	if client.Cache == nil {
+		return lookup()
+	}
+	return cachedLookup(client.Cache, ipCacheKey(ip), client.Cache.options.IPTTL, lookup)
+}
+
+func (client *Client) getIP(ip string, options *RequestOptions) (IP, error) {
 	path, err := url.JoinPath(client.BaseAPI.String(), "lookup", "ip", ip)
 	if err != nil {
 		return IP{}, fmt.Errorf("creating path for ip request: %w", err)
@@ -84,7 +120,9 @@ func (client *Client) GetIP(ip string, options *RequestOptions) (IP, error) {
 		return IP{}, fmt.Errorf("making request for IP (%s): %w", ip, err)
 	}
 
-	resp, err := requestJSON[IP](options, client, req, http.StatusOK)
+	resp, err := withQuota(client, options, 1, func() (IP, error) {
+		return requestJSON[IP](options, client, &Call{Operation: "GetIP", Subject: ip}, req, http.StatusOK)
+	})
 	if err != nil {
 		return IP{}, fmt.Errorf("requesting JSON data: %w", err)
 	}
@@ -110,7 +148,53 @@ func (client *Client) GetIP(ip string, options *RequestOptions) (IP, error) {
 //	for _, info := range results {
 //		fmt.Printf("%s: risk=%d\n", info.IP, info.Intelligence.RiskScore)
 //	}
+//
+// When client.Cache is set, cached addresses are served from it and only the misses are
+// sent to the server.
+//
+// Every address is validated and canonicalized with ParseAddr first; the first
+// rejected address is returned as an *AddrError without making a request.
 func (client *Client) GetIPs(ips []string, options *RequestOptions) ([]IP, error) {
+	ips, err := canonicalIPs(ips)
+	if err != nil {
+		return []IP{}, err
+	}
+	cache := client.Cache
+	if cache == nil {
+		return client.getIPs(ips, options)
+	}
+	cached, misses, err := cache.cachedIPs(ips)
+	if err != nil {
+		return []IP{}, err
+	}
+	var fetched map[string]IP
+	if len(misses) > 0 {
+		records, err := client.getIPs(misses, options)
+		if err != nil {
+			return []IP{}, err
+		}
+		fetched = resultsByAddr(records)
+	}
+	for _, ip := range misses {
+		record, ok := fetched[ip]
+		if !ok {
+			return []IP{}, fmt.Errorf("looking up %s: %w", ip, errNoResult)
+		}
+		cache.put(ipCacheKey(ip), record, cache.options.IPTTL)
+	}
+
+	results := make([]IP, len(ips))
+	for i, ip := range ips {
+		record, ok := cached[i]
+		if !ok {
+			record = fetched[ip]
+		}
+		results[i] = record
+	}
+	return results, nil
+}
+
+func (client *Client) getIPs(ips []string, options *RequestOptions) ([]IP, error) {
 	path, err := url.JoinPath(client.BaseAPI.String(), "lookup", "ips")
 	if err != nil {
 		return []IP{}, fmt.Errorf("creating path for ips request: %w", err)
@@ -128,10 +212,14 @@ func (client *Client) GetIPs(ips []string, options *RequestOptions) ([]IP, error
 		return []IP{}, fmt.Errorf("making request for ips: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
+	markIdempotent(req)
 
-	resp, err := requestJSON[struct {
+	type results struct {
 		Results []IP `json:"results"`
-	}](options, client, req, http.StatusOK)
+	}
+	resp, err := withQuota(client, options, len(ips), func() (results, error) {
+		return requestJSON[results](options, client, &Call{Operation: "GetIPs", Batch: len(ips)}, req, http.StatusOK)
+	})
 	if err != nil {
 		return []IP{}, fmt.Errorf("requesting JSON data: %w", err)
 	}
@@ -0,0 +1,107 @@
+package synthient
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+)
+
+// WithLogger sets the structured logger used to report requests, redirects, retries,
+// stream lifecycle events and decode failures.
+func WithLogger(logger *slog.Logger) Option {
+	return func(client *Client) {
+		client.Logger = logger
+	}
+}
+
+// WithMaskedSubjects masks looked-up IPs and domains in log output; see MaskSubject.
+func WithMaskedSubjects() Option {
+	return func(client *Client) {
+		client.MaskSubjects = true
+	}
+}
+
+// MaskSubject masks a lookup subject for logging. IPv4 addresses keep their /24
+// network and IPv6 addresses their /48 (e.g. "203.0.113.0/24"); anything else, such
+// as a domain, keeps only its last label (e.g. "*.com").
+func MaskSubject(subject string) string {
+	if subject == "" {
+		return ""
+	}
+	addr, err := netip.ParseAddr(subject)
+	if err == nil {
+		bits := 48
+		if addr.Unmap().Is4() {
+			addr, bits = addr.Unmap(), 24
+		}
+		prefix, err := addr.WithZone("").Prefix(bits)
+		if err == nil {
+			return prefix.String()
+		}
+	}
+	if i := strings.LastIndex(subject, "."); i >= 0 && i < len(subject)-1 {
+		return "*" + subject[i:]
+	}
+	return "*"
+}
+
+// log writes a record to client.Logger, if one is set.
+func (client *Client) log(ctx context.Context, level slog.Level, msg string, call *Call, attrs ...slog.Attr) {
+	logger := client.Logger
+	if logger == nil || !logger.Enabled(ctx, level) {
+		return
+	}
+	if call != nil {
+		attrs = append([]slog.Attr{slog.String("operation", call.Operation)}, attrs...)
+		if call.Subject != "" {
+			attrs = append(attrs, slog.String("subject", client.logSubject(call.Subject)))
+		}
+		if call.Stream != "" {
+			attrs = append(attrs, slog.String("stream", call.Stream))
+		}
+	}
+	logger.LogAttrs(ctx, level, msg, attrs...)
+}
+
+func (client *Client) logSubject(subject string) string {
+	if client.MaskSubjects {
+		return MaskSubject(subject)
+	}
+	return subject
+}
+
+// logURL renders u for logs: the query string (which holds presigned credentials on
+// snapshot redirects) is dropped and the call subject is masked when requested.
+func (client *Client) logURL(call *Call, u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	redacted := *u
+	redacted.RawQuery = ""
+	redacted.Fragment = ""
+	redacted.User = nil
+	s := redacted.String()
+	if client.MaskSubjects && call != nil && call.Subject != "" {
+		s = strings.ReplaceAll(s, url.PathEscape(call.Subject), MaskSubject(call.Subject))
+	}
+	return s
+}
+
+// redactedHeader logs HTTP headers with credentials replaced.
+type redactedHeader http.Header
+
+func (h redactedHeader) LogValue() slog.Value {
+	attrs := make([]slog.Attr, 0, len(h))
+	for key, values := range h {
+		value := strings.Join(values, ", ")
+		switch http.CanonicalHeaderKey(key) {
+		case "X-Api-Key", "Authorization", "Cookie", "Set-Cookie":
+			value = "REDACTED"
+		}
+		attrs = append(attrs, slog.String(key, value))
+	}
+	return slog.GroupValue(attrs...)
+}
@@ -0,0 +1,99 @@
+package synthient
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoggerRedactsCredentials(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"ip":"213.149.183.77"}`))
+	}))
+	client.Token = "super-secret-key"
+	client.MaskSubjects = true
+	var buf bytes.Buffer
+	client.Logger = slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	_, err := client.GetIP("213.149.183.77", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if strings.Contains(out, "super-secret-key") {
+		t.Errorf("log output leaked the API key:\n%s", out)
+	}
+	if strings.Contains(out, "213.149.183.77") {
+		t.Errorf("log output leaked the masked subject:\n%s", out)
+	}
+	if !strings.Contains(out, "213.149.183.0/24") || !strings.Contains(out, "X-Api-Key=REDACTED") {
+		t.Errorf("log output missing masked subject or redacted header:\n%s", out)
+	}
+}
+
+func TestLoggerRedactsTransportErrors(t *testing.T) {
+	dead := httptest.NewServer(http.NotFoundHandler())
+	deadURL := dead.URL
+	dead.Close()
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, deadURL+r.URL.Path+"?signature=presigned-secret", http.StatusFound)
+	}))
+	client.MaskSubjects = true
+	client.Retry = &RetryPolicy{MaxAttempts: 2, MinBackoff: time.Millisecond}
+	var buf bytes.Buffer
+	client.Logger = slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	_, err := client.GetIP("213.149.183.77", nil)
+	if err == nil {
+		t.Fatal("request to a closed server succeeded")
+	}
+	out := buf.String()
+	for _, leak := range []string{"213.149.183.77", "presigned-secret"} {
+		if strings.Contains(out, leak) || strings.Contains(err.Error(), leak) {
+			t.Errorf("%q leaked:\nerr = %v\n%s", leak, err, out)
+		}
+	}
+	if !strings.Contains(out, "retrying synthient request") || !strings.Contains(out, "synthient request failed") {
+		t.Errorf("log output missing failure records:\n%s", out)
+	}
+}
+
+func TestAPIErrorURLRedacted(t *testing.T) {
+	denied := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer denied.Close()
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, denied.URL+r.URL.Path+"?signature=presigned-secret", http.StatusTemporaryRedirect)
+	}))
+	client.MaskSubjects = true
+
+	_, err := client.GetIP("213.149.183.77", nil)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("err = %v, want *APIError", err)
+	}
+	if want := denied.URL + "/lookup/ip/213.149.183.0/24"; apiErr.URL != want {
+		t.Errorf("URL = %q, want %q", apiErr.URL, want)
+	}
+}
+
+func TestMaskSubject(t *testing.T) {
+	cases := map[string]string{
+		"203.0.113.77":        "203.0.113.0/24",
+		"2001:db8:1:2::1":     "2001:db8:1::/48",
+		"::ffff:203.0.113.77": "203.0.113.0/24",
+		"login.example.com":   "*.com",
+		"localhost":           "*",
+	}
+	for subject, want := range cases {
+		if got := MaskSubject(subject); got != want {
+			t.Errorf("MaskSubject(%q) = %q, want %q", subject, got, want)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package synthient
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ResponseMeta describes the HTTP response behind a client call. Request it by setting
+// RequestOptions.Response; it is filled in for successful calls and for calls that
+// failed with an *APIError.
+//
+// Numeric header values are -1 when the server did not send the header.
+//
+// Example:
+//
+//	var meta synthient.ResponseMeta
+//	ip, err := client.GetIP("8.8.8.8", &synthient.RequestOptions{Response: &meta})
+//	if err != nil {
+//		log.Fatalf("lookup failed (request %s): %s", meta.RequestID, err)
+//	}
+//	fmt.Println(ip.IP, "credits left:", meta.CreditsRemaining)
+type ResponseMeta struct {
+	// StatusCode and Header are taken from the final response.
+	StatusCode int
+	Header     http.Header
+	// RequestID is the server-assigned request identifier (X-Request-Id).
+	RequestID string
+	// CreditsUsed and CreditsRemaining report lookup credits spent by the call and
+	// left afterwards (X-Credits-Used, X-Credits-Remaining).
+	CreditsUsed      int
+	CreditsRemaining int
+	// RateLimitLimit, RateLimitRemaining and RateLimitReset mirror the
+	// X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset headers;
+	// RateLimitReset is zero when absent.
+	RateLimitLimit     int
+	RateLimitRemaining int
+	RateLimitReset     time.Duration
+	// CacheControl, ETag and LastModified carry the response cache headers.
+	CacheControl string
+	ETag         string
+	LastModified string
+	// Attempts is the number of HTTP attempts made, including retries.
+	Attempts int
+	// Duration is the time from the start of the call until response headers arrived.
+	Duration time.Duration
+}
+
+// newResponseMeta collects metadata from response.
+func newResponseMeta(response *http.Response, attempts int, duration time.Duration) ResponseMeta {
+	header := response.Header
+	meta := ResponseMeta{
+		StatusCode:         response.StatusCode,
+		Header:             header,
+		RequestID:          header.Get("X-Request-Id"),
+		CreditsUsed:        headerInt(header, "X-Credits-Used"),
+		CreditsRemaining:   headerInt(header, "X-Credits-Remaining"),
+		RateLimitLimit:     headerInt(header, "X-RateLimit-Limit"),
+		RateLimitRemaining: headerInt(header, "X-RateLimit-Remaining"),
+		CacheControl:       header.Get("Cache-Control"),
+		ETag:               header.Get("ETag"),
+		LastModified:       header.Get("Last-Modified"),
+		Attempts:           attempts,
+		Duration:           duration,
+	}
+	if reset := headerInt(header, "X-RateLimit-Reset"); reset > 0 {
+		meta.RateLimitReset = time.Duration(reset) * time.Second
+	}
+	return meta
+}
+
+// headerInt parses an integer header, returning -1 when it is absent or malformed.
+func headerInt(header http.Header, key string) int {
+	value, err := strconv.Atoi(header.Get(key))
+	if err != nil {
+		return -1
+	}
+	return value
+}
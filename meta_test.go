@@ -0,0 +1,41 @@
+package synthient
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestResponseMeta(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "req_abc")
+		w.Header().Set("X-Credits-Remaining", "41")
+		w.Header().Set("Cache-Control", "max-age=60")
+		if r.URL.Path == "/lookup/domain/example.com" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"ip":"8.8.8.8"}`))
+	}))
+
+	var meta ResponseMeta
+	_, err := client.GetIP("8.8.8.8", &RequestOptions{Response: &meta})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.StatusCode != http.StatusOK || meta.RequestID != "req_abc" || meta.Attempts != 1 {
+		t.Errorf("meta = %+v", meta)
+	}
+	if meta.CreditsRemaining != 41 || meta.CreditsUsed != -1 || meta.CacheControl != "max-age=60" {
+		t.Errorf("meta credits/cache = %d %d %q", meta.CreditsRemaining, meta.CreditsUsed, meta.CacheControl)
+	}
+
+	meta = ResponseMeta{}
+	_, err = client.GetDomain("example.com", &RequestOptions{Response: &meta})
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("err = %v, want ErrNotFound", err)
+	}
+	if meta.StatusCode != http.StatusNotFound || meta.RequestID != "req_abc" {
+		t.Errorf("meta on error = %+v", meta)
+	}
+}
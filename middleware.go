@@ -0,0 +1,82 @@
+package synthient
+
+import (
+	"net/http"
+	"time"
+)
+
+// Call describes the Client method an HTTP request is made for. It is passed to every
+// Middleware so hooks can act per operation rather than per URL.
+type Call struct {
+	// Operation is the name of the Client method, e.g. "GetIP", "StreamProxy" or
+	// "DownloadFeedSnapshot".
+	Operation string
+	// Subject is the IP address or domain being looked up, if any.
+	Subject string
+	// Batch is the number of IPs sent by GetIPs.
+	Batch int
+	// Stream is the feed stream name for feed, stream and download calls.
+	Stream string
+	// Date and Hour identify the snapshot for FeedSnapshotMeta and download calls.
+	Date string
+	Hour *int
+	// Start is when the call began, before any middleware ran.
+	Start time.Time
+
+	attempts int
+}
+
+// Invoker performs the HTTP exchange for a call, including retries and status
+// checks. On success the response body is open and must be consumed by the caller.
+// When the server answered with an unexpected status the error is an *APIError and
+// the response, whose body has already been read and closed, is returned alongside
+// it.
+type Invoker func(req *http.Request) (*http.Response, error)
+
+// DecodeObserver is implemented by response bodies that want to be told about the
+// events a stream decodes from them. Middleware can wrap the body of a stream call in
+// one to observe that connection's events; a wrapper should pass Decoded on to the body
+// it wraps when that is a DecodeObserver too.
+type DecodeObserver interface {
+	// Decoded is called after decoding each event, with the decoding error if there
+	// was one.
+	Decoded(err error)
+}
+
+// Middleware wraps every API, stream and download call made through a Client. It
+// receives the call description and the outgoing request, and must call next to
+// continue the chain (or return its own response to short-circuit it). Middleware
+// runs once per Client method call (once per range of a stream), around all retry
+// attempts, in the order it appears in Client.Middleware: the first entry is the
+// outermost.
+//
+// Example (timing per operation):
+//
+//	client.Middleware = append(client.Middleware, func(
+//		call *synthient.Call,
+//		req *http.Request,
+//		next synthient.Invoker,
+//	) (*http.Response, error) {
+//		resp, err := next(req)
+//		log.Printf("%s took %s", call.Operation, time.Since(call.Start))
+//		return resp, err
+//	})
+type Middleware func(call *Call, req *http.Request, next Invoker) (*http.Response, error)
+
+// WithMiddleware appends middleware to the client's chain.
+func WithMiddleware(middleware ...Middleware) Option {
+	return func(client *Client) {
+		client.Middleware = append(client.Middleware, middleware...)
+	}
+}
+
+// chain wraps invoke with the client's middleware for call.
+func (client *Client) chain(call *Call, invoke Invoker) Invoker {
+	for i := len(client.Middleware) - 1; i >= 0; i-- {
+		middleware, next := client.Middleware[i], invoke
+		invoke = func(req *http.Request) (*http.Response, error) {
+			return middleware(call, req, next)
+		}
+	}
+	return invoke
+}
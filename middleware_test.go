@@ -0,0 +1,82 @@
+package synthient
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestMiddlewareChain(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Trace") != "outer" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"ip":"8.8.8.8"}`))
+	}))
+
+	var order []string
+	var seen Call
+	client.Middleware = []Middleware{
+		func(call *Call, req *http.Request, next Invoker) (*http.Response, error) {
+			order = append(order, "outer")
+			req.Header.Set("X-Trace", "outer")
+			return next(req)
+		},
+		func(call *Call, req *http.Request, next Invoker) (*http.Response, error) {
+			order = append(order, "inner")
+			resp, err := next(req)
+			seen = *call
+			if err == nil && resp.StatusCode != http.StatusOK {
+				t.Errorf("inner middleware saw status %d", resp.StatusCode)
+			}
+			return resp, err
+		},
+	}
+
+	_, err := client.GetIP("8.8.8.8", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(order, []string{"outer", "inner"}) {
+		t.Errorf("middleware order = %v", order)
+	}
+	if seen.Operation != "GetIP" || seen.Subject != "8.8.8.8" || seen.Start.IsZero() {
+		t.Errorf("call = %+v", seen)
+	}
+}
+
+func TestMiddlewareStreamCallPerRange(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"ip":"8.8.8.8"}` + "\n" + `{"ip":"1.1.1.1"}` + "\n"))
+	}))
+	var calls []*Call
+	client.Middleware = []Middleware{
+		func(call *Call, req *http.Request, next Invoker) (*http.Response, error) {
+			calls = append(calls, call)
+			return next(req)
+		},
+	}
+
+	stream := client.StreamProxy(nil)
+	for range 2 {
+		events := 0
+		for _, err := range stream {
+			if err != nil {
+				t.Fatal(err)
+			}
+			events++
+		}
+		if events != 2 {
+			t.Fatalf("ranged %d events, want 2", events)
+		}
+	}
+	if len(calls) != 2 || calls[0] == calls[1] {
+		t.Fatalf("calls = %v, want a separate call per range", calls)
+	}
+	for _, call := range calls {
+		if call.Operation != "StreamProxy" || call.Stream != "proxies" || call.attempts != 1 {
+			t.Errorf("call = %+v", *call)
+		}
+	}
+}
@@ -0,0 +1,336 @@
+// Package otelsynthient instruments a synthient.Client with OpenTelemetry tracing and
+// metrics.
+//
+// It is built on synthient.Middleware: every Client method call opens one span (named
+// "synthient.<Operation>", e.g. "synthient.GetIP") covering all retry attempts, and for
+// streams and downloads the span stays open until the body is closed. Spans carry the
+// looked-up IP or domain, the GetIPs batch size, the stream name and the snapshot date
+// as attributes. The following instruments are recorded:
+//
+//   - synthient.client.duration (histogram, seconds): time until response headers.
+//   - synthient.client.errors (counter): failed calls by error type, derived from the
+//     synthient sentinel errors ("bad_request", "payment_required", ...), and stream
+//     events that failed to decode ("decode").
+//   - synthient.client.stream.events (counter): events decoded from streams.
+//   - synthient.client.download.bytes (counter): snapshot bytes downloaded.
+//
+// The global providers are used unless WithTracerProvider or WithMeterProvider is
+// passed, so the package works unchanged with no-op providers in offline tests.
+//
+// Example:
+//
+//	client := synthient.NewClient(os.Getenv("SYNTHIENT_API_KEY"))
+//	err := otelsynthient.Instrument(&client)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+package otelsynthient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/synthient/go-synthient/v2"
+)
+
+// ScopeName is the instrumentation scope used for the tracer and meter.
+const ScopeName = "github.com/synthient/go-synthient/v2/otelsynthient"
+
+// Attribute keys set on spans and metrics.
+const (
+	AttributeOperation    = attribute.Key("synthient.operation")
+	AttributeIP           = attribute.Key("synthient.ip")
+	AttributeDomain       = attribute.Key("synthient.domain")
+	AttributeBatchSize    = attribute.Key("synthient.batch_size")
+	AttributeStream       = attribute.Key("synthient.stream")
+	AttributeSnapshotDate = attribute.Key("synthient.snapshot.date")
+	AttributeSnapshotHour = attribute.Key("synthient.snapshot.hour")
+	AttributeErrorType    = attribute.Key("error.type")
+)
+
+type config struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+	propagator     propagation.TextMapPropagator
+	omitSubjects   bool
+}
+
+// Option configures the instrumentation.
+type Option func(*config)
+
+// WithTracerProvider sets the tracer provider. Defaults to otel.GetTracerProvider().
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(c *config) {
+		c.tracerProvider = provider
+	}
+}
+
+// WithMeterProvider sets the meter provider. Defaults to otel.GetMeterProvider().
+func WithMeterProvider(provider metric.MeterProvider) Option {
+	return func(c *config) {
+		c.meterProvider = provider
+	}
+}
+
+// WithPropagator sets the propagator used to inject trace context into outgoing
+// requests. Defaults to otel.GetTextMapPropagator().
+func WithPropagator(propagator propagation.TextMapPropagator) Option {
+	return func(c *config) {
+		c.propagator = propagator
+	}
+}
+
+// WithoutSubjects leaves the looked-up IP address or domain off spans.
+func WithoutSubjects() Option {
+	return func(c *config) {
+		c.omitSubjects = true
+	}
+}
+
+type instruments struct {
+	config
+	tracer   trace.Tracer
+	duration metric.Float64Histogram
+	errors   metric.Int64Counter
+	events   metric.Int64Counter
+	bytes    metric.Int64Counter
+}
+
+// Instrument adds the OpenTelemetry middleware to the front of client.Middleware.
+func Instrument(client *synthient.Client, options ...Option) error {
+	middleware, err := Middleware(options...)
+	if err != nil {
+		return err
+	}
+	client.Middleware = append([]synthient.Middleware{middleware}, client.Middleware...)
+	return nil
+}
+
+// Middleware returns a synthient.Middleware that records spans and metrics for every
+// call. Place it first in Client.Middleware so the span covers the rest of the chain,
+// as Instrument does.
+func Middleware(options ...Option) (synthient.Middleware, error) {
+	inst := &instruments{config: config{
+		tracerProvider: otel.GetTracerProvider(),
+		meterProvider:  otel.GetMeterProvider(),
+		propagator:     otel.GetTextMapPropagator(),
+	}}
+	for _, option := range options {
+		option(&inst.config)
+	}
+
+	inst.tracer = inst.tracerProvider.Tracer(ScopeName)
+	meter := inst.meterProvider.Meter(ScopeName)
+	var err error
+	inst.duration, err = meter.Float64Histogram(
+		"synthient.client.duration",
+		metric.WithUnit("s"),
+		metric.WithDescription("Duration of Synthient API calls until response headers are received."),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("creating duration histogram: %w", err)
+	}
+	inst.errors, err = meter.Int64Counter(
+		"synthient.client.errors",
+		metric.WithDescription("Failed Synthient API calls by error type."),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("creating error counter: %w", err)
+	}
+	inst.events, err = meter.Int64Counter(
+		"synthient.client.stream.events",
+		metric.WithDescription("Events read from Synthient real-time streams."),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("creating stream event counter: %w", err)
+	}
+	inst.bytes, err = meter.Int64Counter(
+		"synthient.client.download.bytes",
+		metric.WithUnit("By"),
+		metric.WithDescription("Bytes downloaded from Synthient snapshot exports."),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("creating download byte counter: %w", err)
+	}
+
+	return inst.middleware, nil
+}
+
+func (inst *instruments) middleware(
+	call *synthient.Call,
+	req *http.Request,
+	next synthient.Invoker,
+) (*http.Response, error) {
+	attrs := inst.callAttributes(call)
+	ctx, span := inst.tracer.Start(
+		req.Context(),
+		"synthient."+call.Operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+		trace.WithAttributes(
+			attribute.String("http.request.method", req.Method),
+			attribute.String("server.address", req.URL.Hostname()),
+		),
+	)
+	req = req.WithContext(ctx)
+	inst.propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := next(req)
+	metricAttrs := metric.WithAttributes(attrs[:1]...) // operation only, to bound cardinality
+	inst.duration.Record(ctx, time.Since(call.Start).Seconds(), metricAttrs)
+	if resp != nil {
+		span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	}
+	if err != nil {
+		errorType := ErrorType(err)
+		span.SetAttributes(AttributeErrorType.String(errorType))
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		span.End()
+		inst.errors.Add(ctx, 1, metric.WithAttributes(attrs[0], AttributeErrorType.String(errorType)))
+		return resp, err
+	}
+
+	resp.Body = &instrumentedBody{ReadCloser: resp.Body, ctx: ctx, span: span, inst: inst, call: call}
+	return resp, nil
+}
+
+func (inst *instruments) callAttributes(call *synthient.Call) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{AttributeOperation.String(call.Operation)}
+	if call.Subject != "" && !inst.omitSubjects {
+		if call.Operation == "GetDomain" {
+			attrs = append(attrs, AttributeDomain.String(call.Subject))
+		} else {
+			attrs = append(attrs, AttributeIP.String(call.Subject))
+		}
+	}
+	if call.Batch > 0 {
+		attrs = append(attrs, AttributeBatchSize.Int(call.Batch))
+	}
+	if call.Stream != "" {
+		attrs = append(attrs, AttributeStream.String(call.Stream))
+	}
+	if call.Date != "" {
+		attrs = append(attrs, AttributeSnapshotDate.String(call.Date))
+	}
+	if call.Hour != nil {
+		attrs = append(attrs, AttributeSnapshotHour.Int(*call.Hour))
+	}
+	return attrs
+}
+
+// ErrorType classifies err by the synthient sentinel it wraps. It returns "canceled"
+// and "deadline_exceeded" for context errors and "transport" for anything else.
+func ErrorType(err error) string {
+	switch {
+	case errors.Is(err, synthient.ErrBadRequest):
+		return "bad_request"
+	case errors.Is(err, synthient.ErrUnauthorized):
+		return "unauthorized"
+	case errors.Is(err, synthient.ErrPaymentRequired):
+		return "payment_required"
+	case errors.Is(err, synthient.ErrForbidden):
+		return "forbidden"
+	case errors.Is(err, synthient.ErrNotFound):
+		return "not_found"
+	case errors.Is(err, synthient.ErrTooManyRequests):
+		return "too_many_requests"
+	case errors.Is(err, synthient.ErrInternalServerError):
+		return "internal_server_error"
+	case errors.Is(err, synthient.ErrServiceUnavailable):
+		return "service_unavailable"
+	case errors.Is(err, synthient.ErrUnexpectedStatusCode):
+		return "unexpected_status_code"
+	case errors.Is(err, synthient.ErrQuotaExhausted):
+		return "quota_exhausted"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "deadline_exceeded"
+	default:
+		return "transport"
+	}
+}
+
+// instrumentedBody counts bytes read from a response body and stream events decoded
+// from it, and ends the call's span when the body is closed.
+type instrumentedBody struct {
+	io.ReadCloser
+	ctx    context.Context
+	span   trace.Span
+	inst   *instruments
+	call   *synthient.Call
+	bytes  int64
+	events int64
+	err    error
+	once   sync.Once
+}
+
+func (b *instrumentedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.bytes += int64(n)
+	if err != nil && err != io.EOF {
+		b.err = err
+	}
+	return n, err
+}
+
+func (b *instrumentedBody) attributes() metric.MeasurementOption {
+	return metric.WithAttributes(
+		AttributeOperation.String(b.call.Operation),
+		AttributeStream.String(b.call.Stream),
+	)
+}
+
+// Decoded records a stream event as the stream decodes it, implementing
+// synthient.DecodeObserver.
+func (b *instrumentedBody) Decoded(err error) {
+	if observer, ok := b.ReadCloser.(synthient.DecodeObserver); ok {
+		observer.Decoded(err)
+	}
+	if err == nil {
+		b.events++
+		b.inst.events.Add(b.ctx, 1, b.attributes())
+		return
+	}
+	if errors.Is(err, context.Canceled) || b.ctx.Err() != nil {
+		return
+	}
+	b.err = err
+	b.inst.errors.Add(b.ctx, 1, metric.WithAttributes(
+		AttributeOperation.String(b.call.Operation),
+		AttributeErrorType.String("decode"),
+	))
+}
+
+func (b *instrumentedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() {
+		switch {
+		case strings.HasPrefix(b.call.Operation, "Stream"):
+			b.span.SetAttributes(attribute.Int64("synthient.stream.events", b.events))
+		case strings.HasPrefix(b.call.Operation, "Download"):
+			b.inst.bytes.Add(b.ctx, b.bytes, b.attributes())
+			b.span.SetAttributes(attribute.Int64("synthient.download.bytes", b.bytes))
+		}
+		if b.err != nil && !errors.Is(b.err, context.Canceled) {
+			b.span.RecordError(b.err)
+			b.span.SetStatus(codes.Error, b.err.Error())
+		}
+		b.span.End()
+	})
+	return err
+}
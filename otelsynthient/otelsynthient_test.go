@@ -0,0 +1,237 @@
+package otelsynthient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	tracenoop "go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/synthient/go-synthient/v2"
+)
+
+func newClient(t *testing.T) synthient.Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/lookup/ip/8.8.8.8":
+			_, _ = w.Write([]byte(`{"ip":"8.8.8.8"}`))
+		case "/feeds/proxies/stream":
+			_, _ = w.Write([]byte("{\"ip\":\"1.1.1.1\"}\n{\"ip\":\"1.0.0.1\"}\n"))
+		case "/feeds/torrents/stream":
+			_, _ = w.Write([]byte("{\"ip\":\"1.1.1.1\"}\n{\"ip\":\n"))
+		default:
+			w.WriteHeader(http.StatusPaymentRequired)
+		}
+	}))
+	t.Cleanup(server.Close)
+	base, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return synthient.NewClient("test", synthient.WithHTTPClient(server.Client()), synthient.WithBaseAPI(*base))
+}
+
+func TestSpansAndMetrics(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	reader := sdkmetric.NewManualReader()
+	client := newClient(t)
+	err := Instrument(
+		&client,
+		WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))),
+		WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.GetIP("8.8.8.8", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.GetDomain("example.com", nil)
+	if !errors.Is(err, synthient.ErrPaymentRequired) {
+		t.Fatalf("err = %v, want ErrPaymentRequired", err)
+	}
+	for _, err := range client.StreamProxy(nil) {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("recorded %d spans, want 3", len(spans))
+	}
+	if spans[0].Name() != "synthient.GetIP" || !hasAttribute(spans[0].Attributes(), AttributeIP.String("8.8.8.8")) {
+		t.Errorf("GetIP span = %s %v", spans[0].Name(), spans[0].Attributes())
+	}
+	if !hasAttribute(spans[1].Attributes(), AttributeErrorType.String("payment_required")) {
+		t.Errorf("GetDomain span attributes = %v", spans[1].Attributes())
+	}
+
+	var data metricdata.ResourceMetrics
+	err = reader.Collect(context.Background(), &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sums := map[string]int64{}
+	for _, scope := range data.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			if sum, ok := m.Data.(metricdata.Sum[int64]); ok {
+				for _, point := range sum.DataPoints {
+					sums[m.Name] += point.Value
+				}
+			}
+		}
+	}
+	if sums["synthient.client.errors"] != 1 || sums["synthient.client.stream.events"] != 2 {
+		t.Errorf("counters = %v", sums)
+	}
+}
+
+func TestStreamDecodeErrorsAndOrder(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	client := newClient(t)
+	var traced bool
+	client.Middleware = append(client.Middleware, func(
+		call *synthient.Call,
+		req *http.Request,
+		next synthient.Invoker,
+	) (*http.Response, error) {
+		traced = trace.SpanFromContext(req.Context()).SpanContext().IsValid()
+		return next(req)
+	})
+	err := Instrument(
+		&client,
+		WithTracerProvider(sdktrace.NewTracerProvider()),
+		WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var events int
+	for _, err = range client.StreamTorrent(nil) {
+		if err == nil {
+			events++
+		}
+	}
+	if events != 1 || err == nil {
+		t.Fatalf("decoded %d events, last err = %v; want 1 and a decode error", events, err)
+	}
+	if !traced {
+		t.Error("existing middleware ran outside the span")
+	}
+
+	var data metricdata.ResourceMetrics
+	err = reader.Collect(context.Background(), &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sums := map[string]int64{}
+	for _, scope := range data.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			if sum, ok := m.Data.(metricdata.Sum[int64]); ok {
+				for _, point := range sum.DataPoints {
+					if m.Name == "synthient.client.errors" &&
+						!hasAttribute(point.Attributes.ToSlice(), AttributeErrorType.String("decode")) {
+						continue
+					}
+					sums[m.Name] += point.Value
+				}
+			}
+		}
+	}
+	if sums["synthient.client.errors"] != 1 || sums["synthient.client.stream.events"] != 1 {
+		t.Errorf("counters = %v", sums)
+	}
+}
+
+func TestStreamRangedTwice(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	reader := sdkmetric.NewManualReader()
+	client := newClient(t)
+	err := Instrument(
+		&client,
+		WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))),
+		WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stream := client.StreamProxy(nil)
+	for range 2 {
+		for _, err := range stream {
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	for _, span := range recorder.Ended() {
+		if !hasAttribute(span.Attributes(), attribute.Int64("synthient.stream.events", 2)) {
+			t.Errorf("span %s attributes = %v, want 2 events", span.Name(), span.Attributes())
+		}
+	}
+	var data metricdata.ResourceMetrics
+	err = reader.Collect(context.Background(), &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events int64
+	for _, scope := range data.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			if sum, ok := m.Data.(metricdata.Sum[int64]); ok && m.Name == "synthient.client.stream.events" {
+				for _, point := range sum.DataPoints {
+					events += point.Value
+				}
+			}
+		}
+	}
+	if events != 4 {
+		t.Errorf("synthient.client.stream.events = %d, want 4", events)
+	}
+}
+
+func TestNoopProviders(t *testing.T) {
+	client := newClient(t)
+	err := Instrument(
+		&client,
+		WithTracerProvider(tracenoop.NewTracerProvider()),
+		WithMeterProvider(metricnoop.NewMeterProvider()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := client.DownloadProxy("latest", nil, "", nil)
+	if err == nil {
+		_, _ = io.Copy(io.Discard, r)
+		_ = r.Close()
+	}
+	_, err = client.GetIP("8.8.8.8", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func hasAttribute(attrs []attribute.KeyValue, want attribute.KeyValue) bool {
+	for _, attr := range attrs {
+		if attr == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,223 @@
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/synthient/go-synthient/v2"
+)
+
+// Duration is a time.Duration written as a string such as "90m" or "72h" in policy
+// files. JSON also accepts a number of nanoseconds.
+type Duration time.Duration
+
+// String formats d like time.Duration.
+func (d Duration) String() string { return time.Duration(d).String() }
+
+// MarshalJSON encodes d as a duration string.
+func (d Duration) MarshalJSON() ([]byte, error) { return json.Marshal(d.String()) }
+
+// UnmarshalJSON decodes a duration string or a number of nanoseconds.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var n int64
+	if json.Unmarshal(data, &n) == nil {
+		*d = Duration(n)
+		return nil
+	}
+	var s string
+	err := json.Unmarshal(data, &s)
+	if err != nil {
+		return fmt.Errorf("duration must be a string: %w", err)
+	}
+	return d.parse(s)
+}
+
+// MarshalYAML encodes d as a duration string.
+func (d Duration) MarshalYAML() (any, error) { return d.String(), nil }
+
+// UnmarshalYAML decodes a duration string.
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.ScalarNode {
+		return fmt.Errorf("line %d: duration must be a string", node.Line)
+	}
+	return d.parse(node.Value)
+}
+
+func (d *Duration) parse(s string) error {
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// ParseJSON decodes and validates a JSON policy. Unknown fields are rejected.
+func ParseJSON(data []byte) (*Policy, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var p Policy
+	err := decoder.Decode(&p)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPolicy, err)
+	}
+	return validated(&p)
+}
+
+// ParseYAML decodes and validates a YAML policy. Unknown fields are rejected.
+func ParseYAML(data []byte) (*Policy, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	var p Policy
+	err := decoder.Decode(&p)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPolicy, err)
+	}
+	return validated(&p)
+}
+
+func validated(p *Policy) (*Policy, error) {
+	err := p.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Load reads a policy file, as YAML if its name ends in .yaml or .yml and as JSON
+// otherwise.
+func Load(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading policy %s: %w", path, err)
+	}
+	p, err := parseFile(path, data)
+	if err != nil {
+		return nil, fmt.Errorf("loading policy %s: %w", path, err)
+	}
+	return p, nil
+}
+
+func parseFile(path string, data []byte) (*Policy, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return ParseYAML(data)
+	default:
+		return ParseJSON(data)
+	}
+}
+
+// DefaultCheckInterval is how often a FilePolicy checks its file by default.
+const DefaultCheckInterval = time.Second
+
+// FilePolicy is an Evaluator backed by a policy file that is reloaded whenever the
+// file's modification time or size changes, so a policy can be edited without
+// restarting the process. The file is checked at most once per CheckInterval, during
+// Evaluate.
+//
+// A file that fails to load or validate does not replace the current policy: the
+// previous policy stays in effect, and the error is passed to OnError and returned by
+// Err until a later load succeeds.
+type FilePolicy struct {
+	// CheckInterval is the minimum time between checks of the file. Zero means
+	// DefaultCheckInterval.
+	CheckInterval time.Duration
+	// OnError, if set, is called with each failed automatic reload. It is not
+	// called for errors returned by Reload.
+	OnError func(error)
+
+	path string
+
+	mu        sync.Mutex
+	policy    *Policy
+	err       error
+	checkedAt time.Time
+	modTime   time.Time
+	size      int64
+}
+
+// NewFilePolicy loads the policy at path, as Load does, and returns a FilePolicy
+// that keeps it up to date.
+func NewFilePolicy(path string) (*FilePolicy, error) {
+	file := &FilePolicy{path: path}
+	err := file.Reload()
+	if err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// Policy returns the current policy, reloading the file first if it is due for a
+// check and has changed.
+func (file *FilePolicy) Policy() *Policy {
+	file.mu.Lock()
+	interval := file.CheckInterval
+	if interval <= 0 {
+		interval = DefaultCheckInterval
+	}
+	var err error
+	if time.Since(file.checkedAt) >= interval {
+		err = file.reloadLocked(false)
+	}
+	p := file.policy
+	file.mu.Unlock()
+
+	if err != nil && file.OnError != nil {
+		file.OnError(err)
+	}
+	return p
+}
+
+// Evaluate evaluates the current policy against ip.
+func (file *FilePolicy) Evaluate(ip synthient.IP) Result {
+	return file.Policy().Evaluate(ip)
+}
+
+// Reload reads the file now, even if it has not changed, and returns any error.
+func (file *FilePolicy) Reload() error {
+	file.mu.Lock()
+	defer file.mu.Unlock()
+	return file.reloadLocked(true)
+}
+
+// Err returns the error from the last reload, or nil if it succeeded.
+func (file *FilePolicy) Err() error {
+	file.mu.Lock()
+	defer file.mu.Unlock()
+	return file.err
+}
+
+func (file *FilePolicy) reloadLocked(force bool) error {
+	file.checkedAt = time.Now()
+	info, err := os.Stat(file.path)
+	if err != nil {
+		file.err = fmt.Errorf("reading policy %s: %w", file.path, err)
+		return file.err
+	}
+	if !force && file.policy != nil && info.ModTime().Equal(file.modTime) && info.Size() == file.size {
+		return nil
+	}
+	p, err := Load(file.path)
+	if err != nil {
+		// Remember the failed version so it is not re-read on every check.
+		file.modTime = info.ModTime()
+		file.size = info.Size()
+		file.err = err
+		return err
+	}
+	file.policy = p
+	file.err = nil
+	file.modTime = info.ModTime()
+	file.size = info.Size()
+	return nil
+}
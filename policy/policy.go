@@ -0,0 +1,318 @@
+// Package policy evaluates declarative risk policies against IP lookups.
+//
+// A Policy is an ordered list of rules. Each rule has a condition over the fields of a
+// synthient.IP (risk score, network type, behaviors, categories, country, ASN and how
+// recently a provider was seen) and the Decision to take when it matches. The first
+// matching rule decides; if none matches the policy's Default applies. Every matching
+// rule is reported in the Result, with the reasons it matched, so decisions can be
+// logged and explained.
+//
+// Policies are plain data: they can be built in Go or loaded from YAML or JSON files,
+// and evaluated offline against stored lookups. FilePolicy reloads a policy file when
+// it changes.
+//
+//	default: allow
+//	rules:
+//	  - name: tor
+//	    decision: block
+//	    when:
+//	      is: [tor]
+//	  - name: risky-hosting
+//	    decision: challenge
+//	    when:
+//	      min_risk_score: 70
+//	      network_types: [hosting]
+//	  - name: fresh-proxy
+//	    decision: challenge
+//	    when:
+//	      provider_seen_within: 72h
+//
+// Example:
+//
+//	p, err := policy.Load("policy.yaml")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	info, err := client.GetIP("8.8.8.8", nil)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	result := p.Evaluate(info)
+//	fmt.Println(result.Decision, result.Fired)
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/synthient/go-synthient/v2"
+)
+
+// Decision is the action a policy takes for an IP.
+type Decision string
+
+// Decisions, from least to most restrictive.
+const (
+	Allow     Decision = "allow"
+	Challenge Decision = "challenge"
+	Block     Decision = "block"
+)
+
+// Valid reports whether d is one of the Decision constants.
+func (d Decision) Valid() bool {
+	return d == Allow || d == Challenge || d == Block
+}
+
+// ErrInvalidPolicy is matched by every error Validate, Parse and Load return for a
+// policy that is malformed rather than unreadable.
+var ErrInvalidPolicy = errors.New("invalid policy")
+
+// Evaluator is implemented by Policy and FilePolicy.
+type Evaluator interface {
+	Evaluate(ip synthient.IP) Result
+}
+
+// Policy is an ordered list of rules and the decision taken when none matches.
+type Policy struct {
+	// Default is the decision when no rule matches. Empty means Allow.
+	Default Decision `json:"default,omitempty" yaml:"default,omitempty"`
+	// Rules are tried in order; the first match decides.
+	Rules []Rule `json:"rules" yaml:"rules"`
+}
+
+// Rule takes Decision when its condition matches.
+type Rule struct {
+	// Name identifies the rule in results and errors. It must be unique in a policy.
+	Name     string    `json:"name" yaml:"name"`
+	Decision Decision  `json:"decision" yaml:"decision"`
+	When     Condition `json:"when" yaml:"when"`
+}
+
+// Condition matches IP lookups. Every field that is set must match; list fields match
+// when any of their values does. A Condition with no fields set matches every IP, which
+// is useful as a last catch-all rule.
+//
+// Network types, behaviors, categories and Is values are compared leniently, as by
+// synthient.Category.Is; countries are compared ignoring case.
+type Condition struct {
+	// MinRiskScore matches a RiskScore of at least this value.
+	MinRiskScore *int `json:"min_risk_score,omitempty" yaml:"min_risk_score,omitempty"`
+	// MaxRiskScore matches a RiskScore of at most this value.
+	MaxRiskScore *int `json:"max_risk_score,omitempty" yaml:"max_risk_score,omitempty"`
+	// NetworkTypes matches Network.Type.
+	NetworkTypes []synthient.NetworkType `json:"network_types,omitempty" yaml:"network_types,omitempty"`
+	// Behaviors matches any observed behavior.
+	Behaviors []synthient.Behavior `json:"behaviors,omitempty" yaml:"behaviors,omitempty"`
+	// Categories matches categories and provider types, as IP.HasCategory does.
+	Categories []synthient.Category `json:"categories,omitempty" yaml:"categories,omitempty"`
+	// Is matches the classifications of the IP methods of the same name: "proxy",
+	// "residential_proxy", "vpn", "tor", "hosting" and "residential".
+	Is []string `json:"is,omitempty" yaml:"is,omitempty"`
+	// Countries matches Location.Country.
+	Countries []string `json:"countries,omitempty" yaml:"countries,omitempty"`
+	// NotCountries matches any Location.Country except these.
+	NotCountries []string `json:"not_countries,omitempty" yaml:"not_countries,omitempty"`
+	// ASNs matches Network.Asn.
+	ASNs []int `json:"asns,omitempty" yaml:"asns,omitempty"`
+	// Providers restricts ProviderSeenWithin to the named providers. On its own it
+	// matches IPs any of these providers has been seen using.
+	Providers []string `json:"providers,omitempty" yaml:"providers,omitempty"`
+	// ProviderSeenWithin matches IPs a provider was last seen using no longer ago
+	// than this.
+	ProviderSeenWithin Duration `json:"provider_seen_within,omitempty" yaml:"provider_seen_within,omitempty"`
+}
+
+// classifiers maps Condition.Is values to the IP methods that answer them.
+var classifiers = map[string]func(synthient.IP) bool{
+	"proxy":             synthient.IP.IsProxy,
+	"residential_proxy": synthient.IP.IsResidentialProxy,
+	"vpn":               synthient.IP.IsVPN,
+	"tor":               synthient.IP.IsTor,
+	"hosting":           synthient.IP.IsHosting,
+	"residential":       synthient.IP.IsResidential,
+}
+
+func classifier(name string) func(synthient.IP) bool {
+	return classifiers[strings.ToLower(strings.NewReplacer("-", "_", " ", "_").Replace(name))]
+}
+
+// Result is the outcome of evaluating a policy.
+type Result struct {
+	// Decision is the decision of the first matching rule, or the policy's default.
+	Decision Decision `json:"decision"`
+	// Fired lists every matching rule in policy order. It is empty when the default
+	// applied.
+	Fired []Match `json:"fired,omitempty"`
+}
+
+// Match is a rule that matched an IP.
+type Match struct {
+	Rule     string   `json:"rule"`
+	Decision Decision `json:"decision"`
+	// Reasons describes each part of the condition that matched, e.g.
+	// "risk_score 85 >= 70".
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+// Evaluate evaluates p against ip at the current time.
+func (p *Policy) Evaluate(ip synthient.IP) Result {
+	return p.EvaluateAt(ip, time.Now())
+}
+
+// EvaluateAt evaluates p against ip as of now, which ProviderSeenWithin is measured
+// from. Use it to replay stored lookups as they were evaluated at the time.
+func (p *Policy) EvaluateAt(ip synthient.IP, now time.Time) Result {
+	result := Result{Decision: p.Default}
+	if result.Decision == "" {
+		result.Decision = Allow
+	}
+	for _, rule := range p.Rules {
+		reasons, ok := rule.When.match(ip, now)
+		if !ok {
+			continue
+		}
+		if len(result.Fired) == 0 {
+			result.Decision = rule.Decision
+		}
+		result.Fired = append(result.Fired, Match{Rule: rule.Name, Decision: rule.Decision, Reasons: reasons})
+	}
+	return result
+}
+
+// match reports whether ip satisfies every set field of c, and why.
+func (c Condition) match(ip synthient.IP, now time.Time) ([]string, bool) {
+	var reasons []string
+	score := ip.Intelligence.RiskScore
+	if c.MinRiskScore != nil {
+		if score < *c.MinRiskScore {
+			return nil, false
+		}
+		reasons = append(reasons, fmt.Sprintf("risk_score %d >= %d", score, *c.MinRiskScore))
+	}
+	if c.MaxRiskScore != nil {
+		if score > *c.MaxRiskScore {
+			return nil, false
+		}
+		reasons = append(reasons, fmt.Sprintf("risk_score %d <= %d", score, *c.MaxRiskScore))
+	}
+	if len(c.NetworkTypes) > 0 {
+		if !slices.ContainsFunc(c.NetworkTypes, ip.NetworkType().Is) {
+			return nil, false
+		}
+		reasons = append(reasons, "network type "+ip.Network.Type)
+	}
+	if len(c.Behaviors) > 0 {
+		i := slices.IndexFunc(c.Behaviors, ip.HasBehavior)
+		if i < 0 {
+			return nil, false
+		}
+		reasons = append(reasons, "behavior "+string(c.Behaviors[i]))
+	}
+	if len(c.Categories) > 0 {
+		i := slices.IndexFunc(c.Categories, ip.HasCategory)
+		if i < 0 {
+			return nil, false
+		}
+		reasons = append(reasons, "category "+string(c.Categories[i]))
+	}
+	if len(c.Is) > 0 {
+		i := slices.IndexFunc(c.Is, func(name string) bool {
+			is := classifier(name)
+			return is != nil && is(ip)
+		})
+		if i < 0 {
+			return nil, false
+		}
+		reasons = append(reasons, "is "+c.Is[i])
+	}
+	country := ip.Location.Country
+	if len(c.Countries) > 0 {
+		if !containsFold(c.Countries, country) {
+			return nil, false
+		}
+		reasons = append(reasons, "country "+country)
+	}
+	if len(c.NotCountries) > 0 {
+		if containsFold(c.NotCountries, country) {
+			return nil, false
+		}
+		reasons = append(reasons, fmt.Sprintf("country %q not excluded", country))
+	}
+	if len(c.ASNs) > 0 {
+		if !slices.Contains(c.ASNs, ip.Network.Asn) {
+			return nil, false
+		}
+		reasons = append(reasons, "asn "+strconv.Itoa(ip.Network.Asn))
+	}
+	if len(c.Providers) > 0 || c.ProviderSeenWithin > 0 {
+		reason, ok := c.matchProvider(ip, now)
+		if !ok {
+			return nil, false
+		}
+		reasons = append(reasons, reason)
+	}
+	return reasons, true
+}
+
+func (c Condition) matchProvider(ip synthient.IP, now time.Time) (string, bool) {
+	for _, provider := range ip.Intelligence.Providers {
+		if len(c.Providers) > 0 && !containsFold(c.Providers, provider.Provider) {
+			continue
+		}
+		if c.ProviderSeenWithin <= 0 {
+			return "provider " + provider.Provider, true
+		}
+		seen := provider.LastSeenTime()
+		if seen.IsZero() {
+			continue
+		}
+		age := now.Sub(seen)
+		if age <= time.Duration(c.ProviderSeenWithin) {
+			return fmt.Sprintf("provider %s seen %s ago", provider.Provider, age.Round(time.Second)), true
+		}
+	}
+	return "", false
+}
+
+func containsFold(values []string, value string) bool {
+	return slices.ContainsFunc(values, func(v string) bool { return strings.EqualFold(v, value) })
+}
+
+// Validate reports the first problem with p: an invalid default or rule decision, a
+// missing or duplicate rule name, an unknown Is value, or a MinRiskScore above
+// MaxRiskScore. Errors match ErrInvalidPolicy.
+func (p *Policy) Validate() error {
+	if p.Default != "" && !p.Default.Valid() {
+		return fmt.Errorf("%w: default decision %q", ErrInvalidPolicy, p.Default)
+	}
+	names := make(map[string]bool, len(p.Rules))
+	for i, rule := range p.Rules {
+		if rule.Name == "" {
+			return fmt.Errorf("%w: rule %d has no name", ErrInvalidPolicy, i)
+		}
+		if names[rule.Name] {
+			return fmt.Errorf("%w: duplicate rule %q", ErrInvalidPolicy, rule.Name)
+		}
+		names[rule.Name] = true
+		if !rule.Decision.Valid() {
+			return fmt.Errorf("%w: rule %q: decision %q", ErrInvalidPolicy, rule.Name, rule.Decision)
+		}
+		when := rule.When
+		if when.MinRiskScore != nil && when.MaxRiskScore != nil && *when.MinRiskScore > *when.MaxRiskScore {
+			return fmt.Errorf("%w: rule %q: min_risk_score above max_risk_score", ErrInvalidPolicy, rule.Name)
+		}
+		for _, name := range when.Is {
+			if classifier(name) == nil {
+				return fmt.Errorf("%w: rule %q: unknown is value %q", ErrInvalidPolicy, rule.Name, name)
+			}
+		}
+		if when.ProviderSeenWithin < 0 {
+			return fmt.Errorf("%w: rule %q: negative provider_seen_within", ErrInvalidPolicy, rule.Name)
+		}
+	}
+	return nil
+}
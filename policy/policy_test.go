@@ -0,0 +1,204 @@
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/synthient/go-synthient/v2"
+)
+
+const testYAML = `
+default: allow
+rules:
+  - name: tor
+    decision: block
+    when:
+      is: [tor]
+  - name: risky-hosting
+    decision: challenge
+    when:
+      min_risk_score: 70
+      network_types: [hosting]
+  - name: fresh-proxy
+    decision: challenge
+    when:
+      provider_seen_within: 72h
+  - name: outside-eu
+    decision: challenge
+    when:
+      not_countries: [DE, FR, NL]
+      behaviors: [credential_stuffing]
+`
+
+var testNow = time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+func lookup(t *testing.T, data string) synthient.IP {
+	t.Helper()
+	var ip synthient.IP
+	err := json.Unmarshal([]byte(data), &ip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ip
+}
+
+func TestEvaluate(t *testing.T) {
+	p, err := ParseYAML([]byte(testYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		ip       string
+		decision Decision
+		fired    []string
+	}{
+		{
+			name:     "clean",
+			ip:       `{"ip":"213.149.183.127","network":{"type":"isp"},"location":{"country":"DE"},"intelligence":{"risk_score":10}}`,
+			decision: Allow,
+		},
+		{
+			name:     "tor and hosting",
+			ip:       `{"ip":"213.149.183.77","network":{"type":"hosting"},"intelligence":{"risk_score":95,"categories":["TOR"]}}`,
+			decision: Block,
+			fired:    []string{"tor", "risky-hosting"},
+		},
+		{
+			name:     "recent provider",
+			ip:       `{"ip":"213.149.183.127","intelligence":{"providers":[{"provider":"old","type":"PROXY","last_seen":1700000000},{"provider":"new","type":"RESIDENTIAL_PROXY","last_seen":1773100000000}]}}`,
+			decision: Challenge,
+			fired:    []string{"fresh-proxy"},
+		},
+		{
+			name:     "stale provider",
+			ip:       `{"ip":"213.149.183.127","location":{"country":"fr"},"intelligence":{"behavior":["CREDENTIAL_STUFFING"],"providers":[{"provider":"old","type":"PROXY","last_seen":1700000000}]}}`,
+			decision: Allow,
+		},
+		{
+			name:     "behavior outside countries",
+			ip:       `{"ip":"213.149.183.127","location":{"country":"US"},"intelligence":{"behavior":["CREDENTIAL_STUFFING"]}}`,
+			decision: Challenge,
+			fired:    []string{"outside-eu"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := p.EvaluateAt(lookup(t, test.ip), testNow)
+			if result.Decision != test.decision {
+				t.Errorf("Decision = %s, want %s", result.Decision, test.decision)
+			}
+			var fired []string
+			for _, match := range result.Fired {
+				fired = append(fired, match.Rule)
+				if len(match.Reasons) == 0 {
+					t.Errorf("rule %s fired without reasons", match.Rule)
+				}
+			}
+			if !slices.Equal(fired, test.fired) {
+				t.Errorf("Fired = %v, want %v", fired, test.fired)
+			}
+		})
+	}
+}
+
+func TestParseJSONMatchesYAML(t *testing.T) {
+	fromYAML, err := ParseYAML([]byte(testYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(fromYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromJSON, err := ParseJSON(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fromJSON.Rules[2].When.ProviderSeenWithin != Duration(72*time.Hour) {
+		t.Errorf("provider_seen_within = %s after round trip", fromJSON.Rules[2].When.ProviderSeenWithin)
+	}
+	ip := lookup(t, `{"ip":"213.149.183.77","network":{"type":"HOSTING"},"intelligence":{"risk_score":80}}`)
+	if a, b := fromYAML.EvaluateAt(ip, testNow), fromJSON.EvaluateAt(ip, testNow); a.Decision != b.Decision || a.Decision != Challenge {
+		t.Errorf("decisions = %s, %s; want challenge", a.Decision, b.Decision)
+	}
+}
+
+func TestParseRejectsInvalid(t *testing.T) {
+	for name, data := range map[string]string{
+		"unknown field":    `{"rules":[{"name":"a","decision":"block","when":{"risk":5}}]}`,
+		"unknown decision": `{"rules":[{"name":"a","decision":"deny"}]}`,
+		"missing name":     `{"rules":[{"decision":"block"}]}`,
+		"duplicate name":   `{"rules":[{"name":"a","decision":"block"},{"name":"a","decision":"allow"}]}`,
+		"unknown is":       `{"rules":[{"name":"a","decision":"block","when":{"is":["botnet"]}}]}`,
+		"score range":      `{"rules":[{"name":"a","decision":"block","when":{"min_risk_score":80,"max_risk_score":20}}]}`,
+		"bad duration":     `{"rules":[{"name":"a","decision":"block","when":{"provider_seen_within":"3 days"}}]}`,
+	} {
+		_, err := ParseJSON([]byte(data))
+		if !errors.Is(err, ErrInvalidPolicy) {
+			t.Errorf("%s: err = %v, want ErrInvalidPolicy", name, err)
+		}
+	}
+	_, err := ParseYAML([]byte("rules:\n  - name: a\n    decision: block\n    when:\n      min_score: 5\n"))
+	if !errors.Is(err, ErrInvalidPolicy) {
+		t.Errorf("yaml unknown field: err = %v, want ErrInvalidPolicy", err)
+	}
+}
+
+func TestFilePolicyReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	write := func(data string, mtime time.Time) {
+		t.Helper()
+		err := os.WriteFile(path, []byte(data), 0o600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.Chtimes(path, mtime, mtime)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	start := time.Now()
+	write("default: challenge\n", start)
+
+	file, err := NewFilePolicy(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.CheckInterval = time.Nanosecond
+	var reloadErrs []error
+	file.OnError = func(err error) { reloadErrs = append(reloadErrs, err) }
+
+	ip := lookup(t, `{"ip":"213.149.183.127"}`)
+	if got := file.Evaluate(ip).Decision; got != Challenge {
+		t.Fatalf("Decision = %s, want challenge", got)
+	}
+
+	write("default: block\n", start.Add(time.Minute))
+	if got := file.Evaluate(ip).Decision; got != Block {
+		t.Fatalf("Decision after edit = %s, want block", got)
+	}
+
+	write("default: maybe\n", start.Add(2*time.Minute))
+	if got := file.Evaluate(ip).Decision; got != Block {
+		t.Fatalf("Decision after invalid edit = %s, want block", got)
+	}
+	if !errors.Is(file.Err(), ErrInvalidPolicy) || len(reloadErrs) != 1 {
+		t.Fatalf("Err() = %v, OnError calls = %d", file.Err(), len(reloadErrs))
+	}
+	file.Evaluate(ip)
+	if len(reloadErrs) != 1 {
+		t.Errorf("unchanged invalid file reported %d times", len(reloadErrs))
+	}
+
+	write("default: allow\n", start.Add(3*time.Minute))
+	if got := file.Evaluate(ip).Decision; got != Allow || file.Err() != nil {
+		t.Fatalf("Decision after fix = %s, Err() = %v", got, file.Err())
+	}
+}
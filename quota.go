@@ -0,0 +1,258 @@
+package synthient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// QuotaLimiter is a client-side guard for the organization's lookup credits.
+//
+// It is seeded from Account.LookupQuota and counts credits down as GetIP, GetIPs and
+// GetDomain succeed (one credit per IP or domain). Lookups that would exceed the
+// remaining budget either fail fast with ErrQuotaExhausted or, when Wait is set, block
+// until the quota resets. Once ResetsIn has elapsed the limiter calls Refresh to reload
+// the quota; without a Refresh function it stops enforcing until Update is called again.
+// When Refresh fails the limiter stops enforcing as well, and calls Refresh again after
+// MinQuotaRefresh.
+// A ResetsIn of zero or less means the reset time is unknown; the quota is then reloaded
+// after MinQuotaRefresh. A lookup costing more credits than the quota has ever held
+// fails with ErrQuotaExhausted even when Wait is set, since no reset would let it
+// through.
+//
+// A QuotaLimiter is safe for concurrent use and is meant to be shared by every goroutine
+// using the same Client. Attach it through Client.Limiter, or let Client.SyncQuota
+// create and seed one:
+//
+//	err := client.SyncQuota(nil)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	fmt.Println("credits left:", client.Limiter.Remaining())
+type QuotaLimiter struct {
+	// Wait makes lookups block until the quota resets instead of failing with
+	// ErrQuotaExhausted. Waiting still stops when the request context is cancelled.
+	Wait bool
+	// Refresh reloads the quota once the reset time has passed. Client.SyncQuota sets
+	// it to call GetAccount.
+	Refresh func(ctx context.Context) (Account, error)
+
+	mu         sync.Mutex
+	known      bool
+	credits    int
+	capacity   int
+	reserved   int
+	resetsAt   time.Time
+	refreshing bool
+	changed    chan struct{}
+}
+
+// MinQuotaRefresh is how long a QuotaLimiter keeps a quota whose reset time is unknown
+// before reloading it, and how long it waits to retry a failed Refresh.
+const MinQuotaRefresh = 30 * time.Second
+
+// NewQuotaLimiter returns a QuotaLimiter seeded from account.LookupQuota.
+func NewQuotaLimiter(account Account) *QuotaLimiter {
+	limiter := &QuotaLimiter{}
+	limiter.Update(account)
+	return limiter
+}
+
+// Update replaces the tracked quota with account.LookupQuota. Credits reserved by
+// in-flight lookups remain reserved.
+func (limiter *QuotaLimiter) Update(account Account) {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	limiter.updateLocked(account)
+}
+
+func (limiter *QuotaLimiter) updateLocked(account Account) {
+	limiter.known = true
+	limiter.credits = account.LookupQuota.Credits
+	limiter.capacity = max(limiter.capacity, limiter.credits)
+	resetsIn := time.Duration(account.LookupQuota.ResetsIn) * time.Second
+	if resetsIn <= 0 {
+		resetsIn = MinQuotaRefresh
+	}
+	limiter.resetsAt = time.Now().Add(resetsIn)
+	limiter.notifyLocked()
+}
+
+// Remaining returns the credits not yet spent or reserved by in-flight lookups, or -1
+// when the quota is unknown (never seeded, or reset without a successful Refresh).
+func (limiter *QuotaLimiter) Remaining() int {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	if !limiter.known {
+		return -1
+	}
+	return max(limiter.credits-limiter.reserved, 0)
+}
+
+// ResetsAt returns when the tracked quota is expected to reset.
+func (limiter *QuotaLimiter) ResetsAt() time.Time {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	return limiter.resetsAt
+}
+
+// acquire reserves cost credits, refreshing or waiting as configured.
+func (limiter *QuotaLimiter) acquire(ctx context.Context, cost int) error {
+	limiter.mu.Lock()
+	for {
+		if !limiter.resetsAt.IsZero() && !time.Now().Before(limiter.resetsAt) {
+			if limiter.Refresh == nil {
+				limiter.known = false
+			} else if !limiter.refreshing {
+				limiter.refreshing = true
+				limiter.mu.Unlock()
+				account, err := limiter.Refresh(ctx)
+				limiter.mu.Lock()
+				limiter.refreshing = false
+				if err != nil && ctx.Err() != nil {
+					limiter.notifyLocked()
+					limiter.mu.Unlock()
+					return ctx.Err()
+				}
+				if err != nil {
+					// the old quota has reset, so stop enforcing it and try again later
+					limiter.known = false
+					limiter.resetsAt = time.Now().Add(MinQuotaRefresh)
+					limiter.notifyLocked()
+					continue
+				}
+				limiter.updateLocked(account)
+				continue
+			}
+		}
+
+		if !limiter.known || (!limiter.refreshing && limiter.credits-limiter.reserved >= cost) {
+			limiter.reserved += cost
+			limiter.mu.Unlock()
+			return nil
+		}
+		if (!limiter.Wait || limiter.capacity > 0 && cost > limiter.capacity) && !limiter.refreshing {
+			remaining := max(limiter.credits-limiter.reserved, 0)
+			resetsAt := limiter.resetsAt
+			limiter.mu.Unlock()
+			return fmt.Errorf(
+				"%d credits needed, %d remaining until %s: %w",
+				cost,
+				remaining,
+				resetsAt.Format(time.RFC3339),
+				ErrQuotaExhausted,
+			)
+		}
+
+		if limiter.changed == nil {
+			limiter.changed = make(chan struct{})
+		}
+		changed := limiter.changed
+		// while another goroutine refreshes, only its notification matters
+		var timer *time.Timer
+		var reset <-chan time.Time
+		if !limiter.refreshing {
+			timer = time.NewTimer(max(time.Until(limiter.resetsAt), 0))
+			reset = timer.C
+		}
+		limiter.mu.Unlock()
+
+		var err error
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-changed:
+		case <-reset:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if err != nil {
+			return err
+		}
+		limiter.mu.Lock()
+	}
+}
+
+// release returns a reservation of cost credits. When spent is true the credits are
+// deducted from the budget.
+func (limiter *QuotaLimiter) release(cost int, spent bool) {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	limiter.reserved = max(limiter.reserved-cost, 0)
+	if spent && limiter.known {
+		limiter.credits = max(limiter.credits-cost, 0)
+	}
+	limiter.notifyLocked()
+}
+
+// exhaust records that the server reported the credits as used up. An unknown quota
+// stays unknown since there is no reset time to wait for.
+func (limiter *QuotaLimiter) exhaust() {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	if !limiter.known {
+		return
+	}
+	limiter.credits = 0
+	limiter.notifyLocked()
+}
+
+func (limiter *QuotaLimiter) notifyLocked() {
+	if limiter.changed != nil {
+		close(limiter.changed)
+		limiter.changed = nil
+	}
+}
+
+// SyncQuota fetches the account quota with GetAccount and seeds client.Limiter from it,
+// creating the limiter if the client does not have one yet. A limiter created here
+// refreshes itself through GetAccount once the quota resets.
+//
+// Example:
+//
+//	client := synthient.NewClient(os.Getenv("SYNTHIENT_API_KEY"))
+//	err := client.SyncQuota(nil)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	client.Limiter.Wait = true
+func (client *Client) SyncQuota(options *RequestOptions) error {
+	account, err := client.GetAccount(options)
+	if err != nil {
+		return fmt.Errorf("getting account quota: %w", err)
+	}
+	if client.Limiter == nil {
+		client.Limiter = &QuotaLimiter{}
+	}
+	if client.Limiter.Refresh == nil {
+		client.Limiter.Refresh = func(ctx context.Context) (Account, error) {
+			return client.GetAccount(&RequestOptions{Context: ctx})
+		}
+	}
+	client.Limiter.Update(account)
+	return nil
+}
+
+// withQuota runs a credit-consuming lookup under client.Limiter, if one is set.
+func withQuota[T any](client *Client, options *RequestOptions, cost int, lookup func() (T, error)) (T, error) {
+	limiter := client.Limiter
+	if limiter == nil || cost <= 0 {
+		return lookup()
+	}
+
+	err := limiter.acquire(options.context(), cost)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	result, err := lookup()
+	limiter.release(cost, err == nil)
+	if errors.Is(err, ErrPaymentRequired) {
+		limiter.exhaust()
+	}
+	return result, err
+}
@@ -0,0 +1,136 @@
+package synthient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestQuotaLimiterFailsFast(t *testing.T) {
+	var lookups atomic.Int32
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/account/me") {
+			_, _ = w.Write([]byte(`{"lookup_quota":{"credits":2,"resets_in":3600}}`))
+			return
+		}
+		lookups.Add(1)
+		_, _ = w.Write([]byte(`{"ip":"8.8.8.8"}`))
+	}))
+	err := client.SyncQuota(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for range 2 {
+		_, err = client.GetIP("8.8.8.8", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := client.Limiter.Remaining(); got != 0 {
+		t.Fatalf("Remaining() = %d, want 0", got)
+	}
+	_, err = client.GetIP("8.8.8.8", nil)
+	if !errors.Is(err, ErrQuotaExhausted) {
+		t.Fatalf("err = %v, want ErrQuotaExhausted", err)
+	}
+	if lookups.Load() != 2 {
+		t.Fatalf("server saw %d lookups, want 2", lookups.Load())
+	}
+}
+
+func TestQuotaLimiterWaitsForRefresh(t *testing.T) {
+	limiter := &QuotaLimiter{Wait: true}
+	limiter.Update(Account{})
+	limiter.resetsAt = time.Now().Add(20 * time.Millisecond)
+	limiter.Refresh = func(ctx context.Context) (Account, error) {
+		var account Account
+		account.LookupQuota.Credits = 10
+		account.LookupQuota.ResetsIn = 3600
+		return account, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	err := limiter.acquire(ctx, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	limiter.release(3, true)
+	if got := limiter.Remaining(); got != 7 {
+		t.Fatalf("Remaining() = %d, want 7", got)
+	}
+}
+
+func TestQuotaLimiterUnknownResetTime(t *testing.T) {
+	var refreshes atomic.Int32
+	limiter := &QuotaLimiter{Wait: true}
+	limiter.Refresh = func(ctx context.Context) (Account, error) {
+		refreshes.Add(1)
+		return Account{}, nil
+	}
+	limiter.Update(Account{})
+	if until := time.Until(limiter.ResetsAt()); until <= MinQuotaRefresh/2 {
+		t.Fatalf("ResetsAt() is %v away, want about %v", until, MinQuotaRefresh)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := limiter.acquire(ctx, 1)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if refreshes.Load() != 0 {
+		t.Fatalf("Refresh called %d times, want 0", refreshes.Load())
+	}
+}
+
+func TestQuotaLimiterRefreshFails(t *testing.T) {
+	var refreshes atomic.Int32
+	limiter := &QuotaLimiter{}
+	limiter.Update(Account{})
+	limiter.resetsAt = time.Now().Add(-time.Second)
+	limiter.Refresh = func(ctx context.Context) (Account, error) {
+		refreshes.Add(1)
+		return Account{}, ErrInternalServerError
+	}
+
+	for range 10 {
+		err := limiter.acquire(context.Background(), 1)
+		if err != nil {
+			t.Fatalf("acquire after a failed refresh: %v", err)
+		}
+		limiter.release(1, true)
+	}
+	if refreshes.Load() != 1 {
+		t.Fatalf("Refresh called %d times, want 1", refreshes.Load())
+	}
+	if got := limiter.Remaining(); got != -1 {
+		t.Fatalf("Remaining() = %d, want -1", got)
+	}
+	if until := time.Until(limiter.ResetsAt()); until <= MinQuotaRefresh/2 {
+		t.Fatalf("next refresh is %v away, want about %v", until, MinQuotaRefresh)
+	}
+}
+
+func TestQuotaLimiterCostAboveQuota(t *testing.T) {
+	var account Account
+	account.LookupQuota.Credits = 5
+	account.LookupQuota.ResetsIn = 3600
+	limiter := NewQuotaLimiter(account)
+	limiter.Wait = true
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	err := limiter.acquire(ctx, 6)
+	if !errors.Is(err, ErrQuotaExhausted) {
+		t.Fatalf("err = %v, want ErrQuotaExhausted", err)
+	}
+	if ctx.Err() != nil {
+		t.Fatal("acquire waited for the context")
+	}
+}
@@ -0,0 +1,162 @@
+package synthient
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how failed requests are retried.
+//
+// A nil *RetryPolicy on Client disables retries: every request is attempted exactly
+// once. Retries only apply to idempotent requests (GET, HEAD, OPTIONS, TRACE, PUT and
+// DELETE, plus any request carrying an Idempotency-Key or X-Idempotency-Key header,
+// mirroring net/http). Bulk lookups made by GetIPs are read-only and are marked
+// idempotent internally.
+//
+// Between attempts the client waits using exponential backoff with full jitter,
+// starting at MinBackoff and doubling up to MaxBackoff. When the server sends a
+// Retry-After header (seconds or an HTTP date) that delay is used instead, capped at
+// MaxRetryAfter when it is non-zero. Waiting stops immediately when the request
+// context is cancelled.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first. Values
+	// below 2 disable retries.
+	MaxAttempts int
+	// MinBackoff is the base delay before the first retry. Defaults to 250ms.
+	MinBackoff time.Duration
+	// MaxBackoff caps the computed backoff delay. Defaults to 10s.
+	MaxBackoff time.Duration
+	// MaxRetryAfter caps delays requested by the server through Retry-After. Zero
+	// means the server's value is always honored.
+	MaxRetryAfter time.Duration
+	// Retryable reports whether a failed attempt should be retried. It receives the
+	// error produced by the attempt, which wraps the status sentinels in error.go
+	// for HTTP failures. When nil, DefaultRetryable is used.
+	Retryable func(err error) bool
+}
+
+// DefaultRetryPolicy returns a RetryPolicy with 4 attempts, 250ms to 10s of backoff,
+// and Retry-After delays capped at one minute.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:   4,
+		MinBackoff:    250 * time.Millisecond,
+		MaxBackoff:    10 * time.Second,
+		MaxRetryAfter: time.Minute,
+	}
+}
+
+// DefaultRetryable reports whether err is worth retrying: 429 and 5xx responses other
+// than 501 Not Implemented, and transport errors such as connection resets. Context
+// cancellation and deadline errors are never retried.
+func DefaultRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		code := apiErr.StatusCode
+		return code == http.StatusTooManyRequests ||
+			(code >= 500 && code != http.StatusNotImplemented)
+	}
+	// anything that never produced a response (dial failures, resets, EOFs)
+	return true
+}
+
+func (policy *RetryPolicy) attempts() int {
+	if policy == nil || policy.MaxAttempts < 1 {
+		return 1
+	}
+	return policy.MaxAttempts
+}
+
+func (policy *RetryPolicy) retryable(err error) bool {
+	if policy.Retryable != nil {
+		return policy.Retryable(err)
+	}
+	return DefaultRetryable(err)
+}
+
+// backoff returns the delay before retry number attempt (1 for the first retry).
+func (policy *RetryPolicy) backoff(attempt int, header http.Header) time.Duration {
+	if wait, ok := retryAfter(header, time.Now()); ok {
+		if policy.MaxRetryAfter > 0 && wait > policy.MaxRetryAfter {
+			wait = policy.MaxRetryAfter
+		}
+		return wait
+	}
+
+	minimum := policy.MinBackoff
+	if minimum <= 0 {
+		minimum = 250 * time.Millisecond
+	}
+	maximum := policy.MaxBackoff
+	if maximum <= 0 {
+		maximum = 10 * time.Second
+	}
+	ceiling := minimum << min(attempt-1, 30)
+	if ceiling <= 0 || ceiling > maximum {
+		ceiling = maximum
+	}
+	return rand.N(ceiling + 1)
+}
+
+// retryAfter parses a Retry-After header given either as delta-seconds or an HTTP date.
+func retryAfter(header http.Header, now time.Time) (time.Duration, bool) {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	seconds, err := strconv.Atoi(value)
+	if err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	return max(date.Sub(now), 0), true
+}
+
+// isIdempotent follows the rules net/http uses to decide whether a request may be
+// replayed.
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace,
+		http.MethodPut, http.MethodDelete:
+		return true
+	}
+	_, ok := req.Header["Idempotency-Key"]
+	if !ok {
+		_, ok = req.Header["X-Idempotency-Key"]
+	}
+	return ok
+}
+
+// markIdempotent flags req as safe to replay without sending an extra header; a nil
+// Idempotency-Key value is recognised by net/http but never written to the wire.
+func markIdempotent(req *http.Request) {
+	req.Header["Idempotency-Key"] = nil
+}
+
+// sleepContext waits for d or until ctx is done, whichever happens first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
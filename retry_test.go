@@ -0,0 +1,97 @@
+package synthient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestClient(t *testing.T, handler http.Handler) Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	base, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Client{HttpClient: server.Client(), Token: "test", BaseAPI: *base}
+}
+
+func TestRetryTransientFailures(t *testing.T) {
+	var calls atomic.Int32
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"results":[{"ip":"1.1.1.1"}]}`))
+	}))
+	client.Retry = &RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond}
+
+	ips, err := client.GetIPs([]string{"1.1.1.1"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ips) != 1 || calls.Load() != 3 {
+		t.Fatalf("got %d results after %d calls, want 1 after 3", len(ips), calls.Load())
+	}
+}
+
+func TestRetryStopsOnPermanentError(t *testing.T) {
+	var calls atomic.Int32
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	client.Retry = &RetryPolicy{MaxAttempts: 5, MinBackoff: time.Millisecond}
+
+	_, err := client.GetIP("1.1.1.1", nil)
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("err = %v, want ErrUnauthorized", err)
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("server called %d times, want 1", calls.Load())
+	}
+}
+
+func TestRetryHonorsContext(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	client.Retry = DefaultRetryPolicy()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := client.GetIP("1.1.1.1", &RequestOptions{Context: ctx})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("retry wait ignored cancellation, took %s", elapsed)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2026, 5, 7, 12, 0, 0, 0, time.UTC)
+	cases := map[string]time.Duration{
+		"5":                             5 * time.Second,
+		"Thu, 07 May 2026 12:00:10 GMT": 10 * time.Second,
+		"Thu, 07 May 2026 11:00:00 GMT": 0,
+	}
+	for value, want := range cases {
+		got, ok := retryAfter(http.Header{"Retry-After": {value}}, now)
+		if !ok || got != want {
+			t.Errorf("retryAfter(%q) = %s, %t; want %s, true", value, got, ok, want)
+		}
+	}
+	if _, ok := retryAfter(http.Header{"Retry-After": {"soon"}}, now); ok {
+		t.Error("retryAfter accepted an invalid value")
+	}
+}
@@ -0,0 +1,217 @@
+// Package synthientgrpc provides gRPC server interceptors that look up each call's
+// client IP and gate the call on the result.
+//
+// For every call the interceptors find the client address from the peer (following
+// forwarding metadata only from trusted proxies), look it up through a
+// synthient.IPLookuper such as *synthient.Client, store the lookup in the call context
+// and ask a decision function whether to let the call through. Blocked calls fail with
+// codes.PermissionDenied. When the lookup fails, Options.OnAPIError and
+// Options.OnNoCredits choose between letting the call through (fail open) and failing
+// it with codes.Unavailable (fail closed).
+//
+// Give the client a synthient.LookupCache (synthient.WithCache) so repeat callers do
+// not cost a lookup each, and set Options.Timeout to bound the latency the lookup adds;
+// a slow lookup is abandoned after the timeout and handled as a failed one.
+//
+// Example:
+//
+//	client := synthient.NewClient(os.Getenv("SYNTHIENT_API_KEY"),
+//		synthient.WithCache(synthient.NewLookupCache(synthient.CacheOptions{})))
+//	gate := &synthientgrpc.Options{
+//		TrustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+//		Decide:         synthientgrpc.BlockAbove(80),
+//		Timeout:        200 * time.Millisecond,
+//	}
+//	server := grpc.NewServer(
+//		grpc.ChainUnaryInterceptor(synthientgrpc.UnaryServerInterceptor(&client, gate)),
+//		grpc.ChainStreamInterceptor(synthientgrpc.StreamServerInterceptor(&client, gate)),
+//	)
+//
+// Handlers read the lookup back with FromContext or IPFromContext.
+package synthientgrpc
+
+import (
+	"context"
+	"net/netip"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/synthient/go-synthient/v2"
+	"github.com/synthient/go-synthient/v2/internal/clientip"
+	"github.com/synthient/go-synthient/v2/internal/ipgate"
+	"github.com/synthient/go-synthient/v2/policy"
+)
+
+// DecideFunc decides whether a call to fullMethod from a looked-up IP may proceed.
+// Challenge lets the call through with the decision recorded in its Result, since
+// gRPC has no way to challenge a caller; the handler can act on it.
+type DecideFunc func(ctx context.Context, fullMethod string, ip synthient.IP) policy.Decision
+
+// BlockAbove returns a DecideFunc that blocks IPs with a RiskScore of at least score
+// and allows the rest.
+func BlockAbove(score int) DecideFunc {
+	decide := ipgate.BlockAbove(score)
+	return func(_ context.Context, _ string, ip synthient.IP) policy.Decision { return decide(ip) }
+}
+
+// Evaluate returns a DecideFunc that decides with a risk policy, such as a
+// *policy.Policy or a hot-reloaded *policy.FilePolicy.
+func Evaluate(evaluator policy.Evaluator) DecideFunc {
+	decide := ipgate.Evaluate(evaluator)
+	return func(_ context.Context, _ string, ip synthient.IP) policy.Decision { return decide(ip) }
+}
+
+// FailureMode chooses what happens to a call whose lookup failed: FailOpen lets it
+// through without a lookup result, and FailClosed fails it with codes.Unavailable.
+type FailureMode = ipgate.FailureMode
+
+// Failure modes.
+const (
+	FailOpen   = ipgate.FailOpen
+	FailClosed = ipgate.FailClosed
+)
+
+// DefaultHeaders are the forwarding metadata keys consulted by default.
+var DefaultHeaders = []string{"forwarded", "x-forwarded-for", "x-real-ip"}
+
+// Options configures the interceptors. The zero value looks up the peer address,
+// allows every call and fails open.
+type Options struct {
+	// TrustedProxies are the networks of proxies whose forwarding metadata is
+	// believed. Calls from other peers are attributed to the peer address.
+	TrustedProxies []netip.Prefix
+	// Headers are the forwarding metadata keys consulted, in order, for calls from a
+	// trusted proxy. Defaults to DefaultHeaders.
+	Headers []string
+	// Decide decides each looked-up call. Nil allows every call, so the interceptors
+	// only enrich the context.
+	Decide DecideFunc
+	// Skip, if set, exempts methods (such as health checks) from lookups entirely.
+	Skip func(fullMethod string) bool
+	// Timeout bounds each lookup. Zero leaves it to the call context and the client.
+	Timeout time.Duration
+	// OnAPIError handles lookups that fail for any reason other than running out of
+	// credits. Defaults to FailOpen.
+	OnAPIError FailureMode
+	// OnNoCredits handles lookups that fail with synthient.ErrPaymentRequired or
+	// synthient.ErrQuotaExhausted. Defaults to FailOpen.
+	OnNoCredits FailureMode
+	// OnError, if set, is called with every failed lookup.
+	OnError func(ctx context.Context, fullMethod string, err error)
+}
+
+// Result is what the interceptors learned about a call: the client address it was
+// attributed to, the lookup or its error, and the decision taken.
+type Result = ipgate.Result
+
+// NewContext returns a copy of ctx carrying result. It is useful for testing handlers
+// that read the result.
+func NewContext(ctx context.Context, result *Result) context.Context {
+	return ipgate.NewContext(ctx, result)
+}
+
+// FromContext returns the Result stored by the interceptors.
+func FromContext(ctx context.Context) (*Result, bool) { return ipgate.FromContext(ctx) }
+
+// IPFromContext returns the lookup stored by the interceptors. ok is false when there
+// is none or the lookup failed.
+func IPFromContext(ctx context.Context) (ip synthient.IP, ok bool) {
+	return ipgate.IPFromContext(ctx)
+}
+
+// UnaryServerInterceptor returns an interceptor that looks up and decides unary calls
+// as described in the package documentation. A nil options uses the defaults.
+func UnaryServerInterceptor(lookups synthient.IPLookuper, options *Options) grpc.UnaryServerInterceptor {
+	gate := newGate(lookups, options)
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := gate.check(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor returns an interceptor that looks up and decides streaming
+// calls once, when the stream opens. A nil options uses the defaults.
+func StreamServerInterceptor(lookups synthient.IPLookuper, options *Options) grpc.StreamServerInterceptor {
+	gate := newGate(lookups, options)
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := gate.check(stream.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &serverStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+// serverStream overrides the context of a grpc.ServerStream.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (stream *serverStream) Context() context.Context { return stream.ctx }
+
+type gate struct {
+	Options
+	core ipgate.Gate
+}
+
+func newGate(lookups synthient.IPLookuper, options *Options) *gate {
+	g := &gate{}
+	if options != nil {
+		g.Options = *options
+	}
+	if g.Headers == nil {
+		g.Headers = DefaultHeaders
+	}
+	g.core = ipgate.Gate{
+		Lookups:        lookups,
+		TrustedProxies: g.TrustedProxies,
+		Headers:        g.Headers,
+		Timeout:        g.Timeout,
+		OnAPIError:     g.OnAPIError,
+		OnNoCredits:    g.OnNoCredits,
+	}
+	return g
+}
+
+// check looks up the caller of ctx and returns the context to continue with, or the
+// status error to fail the call with.
+func (g *gate) check(ctx context.Context, fullMethod string) (context.Context, error) {
+	if g.Skip != nil && g.Skip(fullMethod) {
+		return ctx, nil
+	}
+	result := g.lookup(ctx, fullMethod)
+	ctx = NewContext(ctx, result)
+	if result.Decision != policy.Block {
+		return ctx, nil
+	}
+	if result.Err != nil {
+		return nil, status.Error(codes.Unavailable, "client ip lookup failed")
+	}
+	return nil, status.Error(codes.PermissionDenied, "client ip blocked")
+}
+
+func (g *gate) lookup(ctx context.Context, fullMethod string) *Result {
+	var remote netip.Addr
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		remote, _ = clientip.ParseHost(p.Addr.String())
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	var decide func(synthient.IP) policy.Decision
+	if g.Decide != nil {
+		decide = func(ip synthient.IP) policy.Decision { return g.Decide(ctx, fullMethod, ip) }
+	}
+	var onError func(error)
+	if g.OnError != nil {
+		onError = func(err error) { g.OnError(ctx, fullMethod, err) }
+	}
+	return g.core.Check(ctx, remote, md.Get, decide, onError)
+}
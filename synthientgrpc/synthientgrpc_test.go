@@ -0,0 +1,156 @@
+package synthientgrpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"slices"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/synthient/go-synthient/v2"
+	"github.com/synthient/go-synthient/v2/synthienttest"
+)
+
+func newFake() *synthienttest.Fake {
+	fake := synthienttest.NewFake()
+	var risky synthient.IP
+	risky.IP = "213.149.183.77"
+	risky.Intelligence.RiskScore = 95
+	fake.AddIP(risky)
+	var clean synthient.IP
+	clean.IP = "213.149.183.127"
+	clean.Intelligence.RiskScore = 5
+	fake.AddIP(clean)
+	return fake
+}
+
+func callContext(remote string, md metadata.MD) context.Context {
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: net.TCPAddrFromAddrPort(netip.MustParseAddrPort(remote))})
+	if md != nil {
+		ctx = metadata.NewIncomingContext(ctx, md)
+	}
+	return ctx
+}
+
+var unaryInfo = &grpc.UnaryServerInfo{FullMethod: "/test.Service/Call"}
+
+// riskHandler returns the risk score the interceptor stored in the context.
+func riskHandler(ctx context.Context, _ any) (any, error) {
+	ip, ok := IPFromContext(ctx)
+	if !ok {
+		return -1, nil
+	}
+	return ip.Intelligence.RiskScore, nil
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	fake := newFake()
+	interceptor := UnaryServerInterceptor(fake, &Options{
+		TrustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+		Decide:         BlockAbove(80),
+		Skip:           func(method string) bool { return method == "/grpc.health.v1.Health/Check" },
+	})
+
+	for range 2 {
+		resp, err := interceptor(callContext("10.0.0.2:5000", metadata.Pairs("x-forwarded-for", "213.149.183.127")), nil, unaryInfo, riskHandler)
+		if err != nil || resp != 5 {
+			t.Fatalf("forwarded clean call = %v, %v", resp, err)
+		}
+	}
+	_, err := interceptor(callContext("213.149.183.77:5000", metadata.Pairs("x-forwarded-for", "213.149.183.127")), nil, unaryInfo, riskHandler)
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("risky call err = %v, want PermissionDenied", err)
+	}
+	resp, err := interceptor(callContext("213.149.183.77:5000", nil), nil, &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"}, riskHandler)
+	if err != nil || resp != -1 {
+		t.Errorf("skipped call = %v, %v", resp, err)
+	}
+
+	want := []string{"GetIP", "GetIP", "GetIP"}
+	if calls := fake.Calls(); !slices.Equal(calls, want) {
+		t.Errorf("lookups = %v, want %v (none for the skipped call)", calls, want)
+	}
+}
+
+func TestUnaryServerInterceptorFailureModes(t *testing.T) {
+	fake := newFake()
+	fake.SetError("GetIP", synthient.ErrPaymentRequired)
+
+	open := UnaryServerInterceptor(fake, &Options{OnAPIError: FailClosed})
+	resp, err := open(callContext("213.149.183.77:5000", nil), nil, unaryInfo, riskHandler)
+	if err != nil || resp != -1 {
+		t.Errorf("fail-open call = %v, %v", resp, err)
+	}
+	closed := UnaryServerInterceptor(fake, &Options{OnNoCredits: FailClosed})
+	_, err = closed(callContext("213.149.183.77:5000", nil), nil, unaryInfo, riskHandler)
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("fail-closed call err = %v, want Unavailable", err)
+	}
+}
+
+func TestUnaryServerInterceptorUnresolvableForwardedClient(t *testing.T) {
+	fake := newFake()
+	var reported []error
+	interceptor := UnaryServerInterceptor(fake, &Options{
+		TrustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+		Decide:         BlockAbove(80),
+		OnAPIError:     FailClosed,
+		OnError:        func(_ context.Context, _ string, err error) { reported = append(reported, err) },
+	})
+
+	_, err := interceptor(callContext("10.0.0.2:5000", metadata.Pairs("x-forwarded-for", "junk, 213.149.183.77")), nil, unaryInfo, riskHandler)
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("junk beyond the client err = %v, want PermissionDenied", err)
+	}
+	for _, md := range []metadata.MD{
+		metadata.Pairs("x-forwarded-for", "213.149.183.77, junk"),
+		metadata.Pairs("forwarded", "for=unknown"),
+		metadata.Pairs("x-forwarded-for", "10.1.2.3"),
+	} {
+		_, err = interceptor(callContext("10.0.0.2:5000", md), nil, unaryInfo, riskHandler)
+		if status.Code(err) != codes.Unavailable {
+			t.Errorf("%v err = %v, want Unavailable", md, err)
+		}
+	}
+	if len(reported) != 3 || !errors.Is(reported[0], synthient.ErrInvalidAddr) {
+		t.Errorf("reported errors = %v, want 3 ErrInvalidAddr", reported)
+	}
+	resp, err := interceptor(callContext("192.168.1.5:5000", nil), nil, unaryInfo, riskHandler)
+	if err != nil || resp != -1 {
+		t.Errorf("direct private call = %v, %v", resp, err)
+	}
+}
+
+type testStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (stream *testStream) Context() context.Context { return stream.ctx }
+
+func TestStreamServerInterceptor(t *testing.T) {
+	interceptor := StreamServerInterceptor(newFake(), &Options{Decide: BlockAbove(80)})
+	info := &grpc.StreamServerInfo{FullMethod: "/test.Service/Stream"}
+
+	var score int
+	handler := func(_ any, stream grpc.ServerStream) error {
+		ip, _ := IPFromContext(stream.Context())
+		score = ip.Intelligence.RiskScore
+		return nil
+	}
+	err := interceptor(nil, &testStream{ctx: callContext("213.149.183.127:5000", nil)}, info, handler)
+	if err != nil || score != 5 {
+		t.Errorf("clean stream = %d, %v", score, err)
+	}
+	err = interceptor(nil, &testStream{ctx: callContext("[::ffff:213.149.183.77]:5000", nil)}, info, handler)
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("risky stream err = %v, want PermissionDenied", err)
+	}
+}
@@ -0,0 +1,180 @@
+// Package synthienthttp is net/http middleware that looks up each request's client IP
+// and gates the request on the result.
+//
+// For every request the middleware finds the client address (following forwarding
+// headers only through trusted proxies), looks it up through a synthient.IPLookuper
+// such as *synthient.Client, stores the lookup in the request context and asks a
+// decision function whether to allow, challenge or block the request. When the lookup
+// fails, Options.OnAPIError and Options.OnNoCredits choose between letting the request
+// through (fail open) and rejecting it (fail closed).
+//
+// Give the client a synthient.LookupCache (synthient.WithCache) so repeat visitors do
+// not cost a lookup each, and set Options.Timeout to bound the latency the lookup adds.
+//
+// Example:
+//
+//	client := synthient.NewClient(os.Getenv("SYNTHIENT_API_KEY"),
+//		synthient.WithCache(synthient.NewLookupCache(synthient.CacheOptions{})))
+//	gate := synthienthttp.Middleware(&client, &synthienthttp.Options{
+//		TrustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+//		Decide:         synthienthttp.BlockAbove(80),
+//		Timeout:        300 * time.Millisecond,
+//	})
+//	http.ListenAndServe(":8080", gate(mux))
+//
+// Handlers read the lookup back with FromContext or IPFromContext.
+package synthienthttp
+
+import (
+	"context"
+	"net/http"
+	"net/netip"
+	"time"
+
+	"github.com/synthient/go-synthient/v2"
+	"github.com/synthient/go-synthient/v2/internal/clientip"
+	"github.com/synthient/go-synthient/v2/internal/ipgate"
+	"github.com/synthient/go-synthient/v2/policy"
+)
+
+// DecideFunc decides what to do with a request from a looked-up IP.
+type DecideFunc func(r *http.Request, ip synthient.IP) policy.Decision
+
+// BlockAbove returns a DecideFunc that blocks IPs with a RiskScore of at least score
+// and allows the rest.
+func BlockAbove(score int) DecideFunc {
+	decide := ipgate.BlockAbove(score)
+	return func(_ *http.Request, ip synthient.IP) policy.Decision { return decide(ip) }
+}
+
+// Evaluate returns a DecideFunc that decides with a risk policy, such as a
+// *policy.Policy or a hot-reloaded *policy.FilePolicy.
+func Evaluate(evaluator policy.Evaluator) DecideFunc {
+	decide := ipgate.Evaluate(evaluator)
+	return func(_ *http.Request, ip synthient.IP) policy.Decision { return decide(ip) }
+}
+
+// FailureMode chooses what happens to a request whose lookup failed: FailOpen lets
+// it through without a lookup result, and FailClosed rejects it through
+// Options.OnBlock.
+type FailureMode = ipgate.FailureMode
+
+// Failure modes.
+const (
+	FailOpen   = ipgate.FailOpen
+	FailClosed = ipgate.FailClosed
+)
+
+// Options configures Middleware. The zero value looks up RemoteAddr, allows every
+// request and fails open.
+type Options struct {
+	// TrustedProxies are the networks of reverse proxies whose forwarding headers are
+	// believed. Requests from other addresses are attributed to RemoteAddr.
+	TrustedProxies []netip.Prefix
+	// Headers are the forwarding headers consulted, in order, for requests from a
+	// trusted proxy. Defaults to Forwarded, X-Forwarded-For and X-Real-IP.
+	Headers []string
+	// Decide decides each looked-up request. Nil allows every request, so the
+	// middleware only enriches the context.
+	Decide DecideFunc
+	// Timeout bounds each lookup. Zero leaves it to the request context and the
+	// client.
+	Timeout time.Duration
+	// OnAPIError handles lookups that fail for any reason other than running out of
+	// credits. Defaults to FailOpen.
+	OnAPIError FailureMode
+	// OnNoCredits handles lookups that fail with synthient.ErrPaymentRequired or
+	// synthient.ErrQuotaExhausted. Defaults to FailOpen.
+	OnNoCredits FailureMode
+	// OnBlock writes the response for blocked and fail-closed requests. The Result
+	// is available through FromContext. Defaults to 403 Forbidden for blocked requests
+	// and 503 Service Unavailable for failed lookups.
+	OnBlock http.Handler
+	// OnChallenge, if set, handles challenged requests instead of the next handler.
+	// Otherwise challenged requests continue with Decision set in their Result.
+	OnChallenge http.Handler
+	// OnError, if set, is called with every failed lookup.
+	OnError func(r *http.Request, err error)
+}
+
+// Result is what the middleware learned about a request: the client address it was
+// attributed to, the lookup or its error, and the decision taken.
+type Result = ipgate.Result
+
+// NewContext returns a copy of ctx carrying result. It is useful for testing handlers
+// that read the result.
+func NewContext(ctx context.Context, result *Result) context.Context {
+	return ipgate.NewContext(ctx, result)
+}
+
+// FromContext returns the Result stored by Middleware.
+func FromContext(ctx context.Context) (*Result, bool) { return ipgate.FromContext(ctx) }
+
+// IPFromContext returns the lookup stored by Middleware. ok is false when there is
+// none or the lookup failed.
+func IPFromContext(ctx context.Context) (ip synthient.IP, ok bool) {
+	return ipgate.IPFromContext(ctx)
+}
+
+// Middleware returns middleware that looks up, records and decides every request as
+// described in the package documentation. A nil options uses the defaults.
+func Middleware(lookups synthient.IPLookuper, options *Options) func(http.Handler) http.Handler {
+	var opts Options
+	if options != nil {
+		opts = *options
+	}
+	if opts.Headers == nil {
+		opts.Headers = clientip.DefaultHeaders
+	}
+	if opts.OnBlock == nil {
+		opts.OnBlock = http.HandlerFunc(defaultBlock)
+	}
+	gate := &ipgate.Gate{
+		Lookups:        lookups,
+		TrustedProxies: opts.TrustedProxies,
+		Headers:        opts.Headers,
+		Timeout:        opts.Timeout,
+		OnAPIError:     opts.OnAPIError,
+		OnNoCredits:    opts.OnNoCredits,
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			result := opts.check(gate, r)
+			r = r.WithContext(NewContext(r.Context(), result))
+			switch result.Decision {
+			case policy.Block:
+				opts.OnBlock.ServeHTTP(w, r)
+			case policy.Challenge:
+				if opts.OnChallenge != nil {
+					opts.OnChallenge.ServeHTTP(w, r)
+					return
+				}
+				next.ServeHTTP(w, r)
+			default:
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+func (opts *Options) check(gate *ipgate.Gate, r *http.Request) *Result {
+	remote, _ := clientip.ParseHost(r.RemoteAddr)
+	var decide func(synthient.IP) policy.Decision
+	if opts.Decide != nil {
+		decide = func(ip synthient.IP) policy.Decision { return opts.Decide(r, ip) }
+	}
+	var onError func(error)
+	if opts.OnError != nil {
+		onError = func(err error) { opts.OnError(r, err) }
+	}
+	return gate.Check(r.Context(), remote, r.Header.Values, decide, onError)
+}
+
+func defaultBlock(w http.ResponseWriter, r *http.Request) {
+	result, _ := FromContext(r.Context())
+	if result != nil && result.Err != nil {
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+	http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+}
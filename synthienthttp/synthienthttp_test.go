@@ -0,0 +1,153 @@
+package synthienthttp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/synthient/go-synthient/v2"
+	"github.com/synthient/go-synthient/v2/policy"
+	"github.com/synthient/go-synthient/v2/synthienttest"
+)
+
+func newFake() *synthienttest.Fake {
+	fake := synthienttest.NewFake()
+	var risky synthient.IP
+	risky.IP = "213.149.183.77"
+	risky.Intelligence.RiskScore = 95
+	fake.AddIP(risky)
+	var clean synthient.IP
+	clean.IP = "213.149.183.127"
+	clean.Intelligence.RiskScore = 5
+	fake.AddIP(clean)
+	return fake
+}
+
+// echo writes the decision and the looked-up IP from the request context.
+var echo = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	result, _ := FromContext(r.Context())
+	ip, _ := IPFromContext(r.Context())
+	fmt.Fprintf(w, "%s %s %d", result.Decision, result.ClientAddr, ip.Intelligence.RiskScore)
+})
+
+func serve(handler http.Handler, remote string, header http.Header) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = remote
+	for name, values := range header {
+		r.Header[name] = values
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestMiddleware(t *testing.T) {
+	handler := Middleware(newFake(), &Options{
+		TrustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+		Decide:         BlockAbove(80),
+	})(echo)
+
+	w := serve(handler, "10.0.0.2:4000", http.Header{"X-Forwarded-For": {"213.149.183.127"}})
+	if w.Code != http.StatusOK || w.Body.String() != "allow 213.149.183.127 5" {
+		t.Errorf("clean request = %d %q", w.Code, w.Body)
+	}
+	w = serve(handler, "10.0.0.2:4000", http.Header{"X-Forwarded-For": {"213.149.183.77"}})
+	if w.Code != http.StatusForbidden {
+		t.Errorf("risky request = %d, want 403", w.Code)
+	}
+	// Headers from untrusted peers are ignored.
+	w = serve(handler, "213.149.183.77:4000", http.Header{"X-Forwarded-For": {"213.149.183.127"}})
+	if w.Code != http.StatusForbidden {
+		t.Errorf("spoofed request = %d, want 403", w.Code)
+	}
+	// Private clients cannot be looked up and pass through, failing open.
+	w = serve(handler, "10.0.0.2:4000", nil)
+	if w.Code != http.StatusOK || w.Body.String() != "allow 10.0.0.2 0" {
+		t.Errorf("private request = %d %q", w.Code, w.Body)
+	}
+}
+
+func TestMiddlewareChallenge(t *testing.T) {
+	minScore := 80
+	p := &policy.Policy{Rules: []policy.Rule{{Name: "risky", Decision: policy.Challenge, When: policy.Condition{MinRiskScore: &minScore}}}}
+	challenge := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+
+	handler := Middleware(newFake(), &Options{Decide: Evaluate(p)})(echo)
+	w := serve(handler, "213.149.183.77:4000", nil)
+	if w.Code != http.StatusOK || w.Body.String() != "challenge 213.149.183.77 95" {
+		t.Errorf("challenged request without OnChallenge = %d %q", w.Code, w.Body)
+	}
+
+	handler = Middleware(newFake(), &Options{Decide: Evaluate(p), OnChallenge: challenge})(echo)
+	w = serve(handler, "213.149.183.77:4000", nil)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("challenged request = %d, want 401", w.Code)
+	}
+}
+
+func TestMiddlewareFailureModes(t *testing.T) {
+	tests := []struct {
+		err     error
+		options Options
+		code    int
+	}{
+		{synthient.ErrInternalServerError, Options{}, http.StatusOK},
+		{synthient.ErrInternalServerError, Options{OnAPIError: FailClosed}, http.StatusServiceUnavailable},
+		{synthient.ErrInternalServerError, Options{OnNoCredits: FailClosed}, http.StatusOK},
+		{synthient.ErrPaymentRequired, Options{OnAPIError: FailClosed}, http.StatusOK},
+		{synthient.ErrQuotaExhausted, Options{OnNoCredits: FailClosed}, http.StatusServiceUnavailable},
+	}
+	for _, test := range tests {
+		fake := newFake()
+		fake.SetError("GetIP", test.err)
+		var reported error
+		test.options.OnError = func(_ *http.Request, err error) { reported = err }
+
+		w := serve(Middleware(fake, &test.options)(echo), "213.149.183.77:4000", nil)
+		if w.Code != test.code {
+			t.Errorf("%v with %+v = %d, want %d", test.err, test.options, w.Code, test.code)
+		}
+		if reported != test.err {
+			t.Errorf("OnError got %v, want %v", reported, test.err)
+		}
+	}
+}
+
+func TestMiddlewareUnresolvableForwardedClient(t *testing.T) {
+	var reported error
+	handler := Middleware(newFake(), &Options{
+		TrustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+		Decide:         BlockAbove(80),
+		OnAPIError:     FailClosed,
+		OnError:        func(_ *http.Request, err error) { reported = err },
+	})(echo)
+
+	// A junk hop beyond the client does not hide it.
+	w := serve(handler, "10.0.0.2:4000", http.Header{"X-Forwarded-For": {"junk, 213.149.183.77"}})
+	if w.Code != http.StatusForbidden {
+		t.Errorf("junk, risky = %d, want 403", w.Code)
+	}
+	// A junk hop where the client should be fails the lookup instead of passing as
+	// the proxy.
+	for _, header := range []http.Header{
+		{"X-Forwarded-For": {"213.149.183.77, junk"}},
+		{"Forwarded": {"for=unknown"}},
+		{"X-Forwarded-For": {"10.1.2.3"}},
+	} {
+		reported = nil
+		w = serve(handler, "10.0.0.2:4000", header)
+		if w.Code != http.StatusServiceUnavailable || !errors.Is(reported, synthient.ErrInvalidAddr) {
+			t.Errorf("%v = %d, OnError got %v; want 503 and ErrInvalidAddr", header, w.Code, reported)
+		}
+	}
+	// Private clients connecting directly are still let through.
+	w = serve(handler, "192.168.1.5:4000", nil)
+	if w.Code != http.StatusOK {
+		t.Errorf("direct private request = %d, want 200", w.Code)
+	}
+}
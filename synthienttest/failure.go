@@ -0,0 +1,77 @@
+package synthienttest
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Failure describes an error response the Server returns instead of handling a
+// request normally.
+type Failure struct {
+	// Method restricts the failure to one HTTP method. Empty matches any method.
+	Method string
+	// Path is a URL path prefix to match, e.g. "/lookup/ip" or "/feeds/proxies".
+	// Empty matches every path.
+	Path string
+	// Status is the HTTP status code to return, e.g. 429 or 503.
+	Status int
+	// Message is returned as the JSON "error" field.
+	Message string
+	// Header is added to the response, e.g. {"Retry-After": {"1"}}.
+	Header http.Header
+	// Times is how many matching requests fail before the failure is removed. Zero
+	// fails every matching request until ClearFailures is called.
+	Times int
+}
+
+// Fail registers an injected failure. Failures are matched in registration order.
+//
+// Example (two 503s before succeeding):
+//
+//	server.Fail(synthienttest.Failure{Path: "/lookup/ip", Status: 503, Times: 2})
+func (server *Server) Fail(failure Failure) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.failures = append(server.failures, &failure)
+}
+
+// ClearFailures removes every injected failure.
+func (server *Server) ClearFailures() {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.failures = nil
+}
+
+// matchFailure returns the first failure matching r, consuming one of its uses.
+// server.mu must be held.
+func (server *Server) matchFailure(r *http.Request) *Failure {
+	for i, failure := range server.failures {
+		if failure.Method != "" && !strings.EqualFold(failure.Method, r.Method) {
+			continue
+		}
+		if !strings.HasPrefix(r.URL.Path, failure.Path) {
+			continue
+		}
+		if failure.Times > 0 {
+			failure.Times--
+			if failure.Times == 0 {
+				server.failures = append(server.failures[:i:i], server.failures[i+1:]...)
+			}
+		}
+		return failure
+	}
+	return nil
+}
+
+func (failure *Failure) write(w http.ResponseWriter) {
+	for key, values := range failure.Header {
+		for _, value := range values {
+			w.Header().Add(key, value)
+		}
+	}
+	msg := failure.Message
+	if msg == "" {
+		msg = strings.ToLower(http.StatusText(failure.Status))
+	}
+	writeError(w, failure.Status, msg)
+}
@@ -0,0 +1,364 @@
+package synthienttest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/synthient/go-synthient/v2"
+)
+
+// Fake is an in-memory implementation of synthient.API for unit tests that do not
+// need an HTTP server. It serves the same seeded data as Server: unknown addresses and
+// domains resolve to records with only their key set, streams replay pushed events, and
+// snapshots registered with AddSnapshot can be listed and downloaded.
+//
+// Unlike a Server connection, a Fake stream ends once every pushed event has been
+// yielded. All methods are safe for concurrent use.
+//
+// Example:
+//
+//	fake := synthienttest.NewFake()
+//	var record synthient.IP
+//	record.IP = "213.149.183.127"
+//	record.Intelligence.RiskScore = 90
+//	fake.AddIP(record)
+//
+//	var lookups synthient.IPLookuper = fake
+//	ip, err := lookups.GetIP("213.149.183.127", nil)
+type Fake struct {
+	mu        sync.Mutex
+	account   synthient.Account
+	ips       map[string]synthient.IP
+	domains   map[string]synthient.Domain
+	events    map[string][][]byte
+	snapshots snapshotStore
+	errs      map[string]error
+	calls     []string
+}
+
+var _ synthient.API = (*Fake)(nil)
+
+// NewFake returns an empty Fake.
+func NewFake() *Fake {
+	return &Fake{
+		ips:       map[string]synthient.IP{},
+		domains:   map[string]synthient.Domain{},
+		events:    map[string][][]byte{},
+		snapshots: snapshotStore{},
+		errs:      map[string]error{},
+	}
+}
+
+// SetAccount sets the value returned by GetAccount.
+func (fake *Fake) SetAccount(account synthient.Account) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	fake.account = account
+}
+
+// AddIP seeds the record returned for ip.IP by GetIP and GetIPs.
+func (fake *Fake) AddIP(ip synthient.IP) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	fake.ips[ip.IP] = ip
+}
+
+// AddDomain seeds the record returned for domain.Domain by GetDomain.
+func (fake *Fake) AddDomain(domain synthient.Domain) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	fake.domains[strings.ToLower(domain.Domain)] = domain
+}
+
+// Push appends events to a stream (e.g. "proxies" or "honeypot_https"). Events are
+// round-tripped through JSON, so maps and partial structs are accepted as with
+// Server.Push.
+func (fake *Fake) Push(stream string, events ...any) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	for _, event := range events {
+		line, err := json.Marshal(event)
+		if err != nil {
+			panic(fmt.Sprintf("synthienttest: encoding %s event: %s", stream, err))
+		}
+		fake.events[stream] = append(fake.events[stream], line)
+	}
+}
+
+// AddSnapshot registers a snapshot for stream and returns its listing entry.
+func (fake *Fake) AddSnapshot(stream string, snapshot Snapshot) synthient.FeedSnapshot {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	return fake.snapshots.add(stream, snapshot).entry()
+}
+
+// SetError makes every call to operation (a method name such as "GetIP" or
+// "StreamProxy", as reported in synthient.Call.Operation) fail with err. A nil err
+// clears the failure.
+func (fake *Fake) SetError(operation string, err error) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if err == nil {
+		delete(fake.errs, operation)
+		return
+	}
+	fake.errs[operation] = err
+}
+
+// Calls returns the operations invoked so far, oldest first.
+func (fake *Fake) Calls() []string {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	return append([]string(nil), fake.calls...)
+}
+
+// begin records a call and returns the error it should fail with, if any.
+func (fake *Fake) begin(operation string, options *synthient.RequestOptions) error {
+	fake.mu.Lock()
+	fake.calls = append(fake.calls, operation)
+	err := fake.errs[operation]
+	fake.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if options != nil && options.Context != nil {
+		return options.Context.Err()
+	}
+	return nil
+}
+
+// GetIP implements synthient.IPLookuper.
+func (fake *Fake) GetIP(ip string, options *synthient.RequestOptions) (synthient.IP, error) {
+	err := fake.begin("GetIP", options)
+	if err != nil {
+		return synthient.IP{}, err
+	}
+	return fake.lookupIP(ip)
+}
+
+// GetIPs implements synthient.IPLookuper.
+func (fake *Fake) GetIPs(ips []string, options *synthient.RequestOptions) ([]synthient.IP, error) {
+	err := fake.begin("GetIPs", options)
+	if err != nil {
+		return []synthient.IP{}, err
+	}
+	results := make([]synthient.IP, 0, len(ips))
+	for _, ip := range ips {
+		record, err := fake.lookupIP(ip)
+		if err != nil {
+			return []synthient.IP{}, err
+		}
+		results = append(results, record)
+	}
+	return results, nil
+}
+
+// lookupIP validates ip as the Client does before returning its record.
+func (fake *Fake) lookupIP(ip string) (synthient.IP, error) {
+	addr, err := synthient.ParseAddr(ip)
+	if err != nil {
+		return synthient.IP{}, err
+	}
+	fake.mu.Lock()
+	record, ok := fake.ips[addr.String()]
+	fake.mu.Unlock()
+	if !ok {
+		record = synthient.IP{IP: addr.String()}
+	}
+	record.Addr = addr
+	return record, nil
+}
+
+// GetDomain implements synthient.DomainLookuper.
+func (fake *Fake) GetDomain(domain string, options *synthient.RequestOptions) (synthient.Domain, error) {
+	err := fake.begin("GetDomain", options)
+	if err != nil {
+		return synthient.Domain{}, err
+	}
+	name := strings.ToLower(domain)
+	if !strings.Contains(name, ".") {
+		return synthient.Domain{}, fmt.Errorf("looking up %q: %w", domain, synthient.ErrBadRequest)
+	}
+	fake.mu.Lock()
+	record, ok := fake.domains[name]
+	fake.mu.Unlock()
+	if !ok {
+		record = synthient.Domain{Domain: name}
+	}
+	return record, nil
+}
+
+// GetAccount implements synthient.AccountGetter.
+func (fake *Fake) GetAccount(options *synthient.RequestOptions) (synthient.Account, error) {
+	err := fake.begin("GetAccount", options)
+	if err != nil {
+		return synthient.Account{}, err
+	}
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	return fake.account, nil
+}
+
+// StreamProxy implements synthient.FeedStreamer.
+func (fake *Fake) StreamProxy(
+	requestOptions *synthient.RequestOptions,
+) iter.Seq2[synthient.ProxyEvent, error] {
+	return fakeStream[synthient.ProxyEvent](fake, requestOptions, "StreamProxy", "proxies")
+}
+
+// StreamAnonymizer implements synthient.FeedStreamer.
+func (fake *Fake) StreamAnonymizer(
+	requestOptions *synthient.RequestOptions,
+) iter.Seq2[synthient.AnonymizerEvent, error] {
+	return fakeStream[synthient.AnonymizerEvent](fake, requestOptions, "StreamAnonymizer", "anonymizers")
+}
+
+// StreamTorrent implements synthient.FeedStreamer.
+func (fake *Fake) StreamTorrent(
+	requestOptions *synthient.RequestOptions,
+) iter.Seq2[synthient.TorrentEvent, error] {
+	return fakeStream[synthient.TorrentEvent](fake, requestOptions, "StreamTorrent", "torrents")
+}
+
+// StreamHeliosHTTP implements synthient.FeedStreamer.
+func (fake *Fake) StreamHeliosHTTP(
+	requestOptions *synthient.RequestOptions,
+) iter.Seq2[synthient.HeliosHTTPEvent, error] {
+	return fakeStream[synthient.HeliosHTTPEvent](fake, requestOptions, "StreamHeliosHTTP", "honeypot_http")
+}
+
+// StreamHeliosTLS implements synthient.FeedStreamer.
+func (fake *Fake) StreamHeliosTLS(
+	requestOptions *synthient.RequestOptions,
+) iter.Seq2[synthient.HeliosTLSEvent, error] {
+	return fakeStream[synthient.HeliosTLSEvent](fake, requestOptions, "StreamHeliosTLS", "honeypot_https")
+}
+
+func fakeStream[T any](
+	fake *Fake,
+	requestOptions *synthient.RequestOptions,
+	operation string,
+	stream string,
+) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		err := fake.begin(operation, requestOptions)
+		if err != nil {
+			yield(zero, fmt.Errorf("connecting to %s stream: %w", stream, err))
+			return
+		}
+		ctx := context.Background()
+		if requestOptions != nil && requestOptions.Context != nil {
+			ctx = requestOptions.Context
+		}
+
+		fake.mu.Lock()
+		lines := fake.events[stream]
+		fake.mu.Unlock()
+		for _, line := range lines {
+			if ctx.Err() != nil {
+				yield(zero, fmt.Errorf("decoding %s stream event: %w", stream, ctx.Err()))
+				return
+			}
+			var event T
+			err = json.Unmarshal(line, &event)
+			if err != nil {
+				yield(zero, fmt.Errorf("decoding %s stream event: %w", stream, err))
+				return
+			}
+			if !yield(event, nil) {
+				return
+			}
+		}
+	}
+}
+
+// FeedSnapshots implements synthient.SnapshotDownloader.
+func (fake *Fake) FeedSnapshots(
+	stream string,
+	options *synthient.FeedSnapshotsOptions,
+	requestOptions *synthient.RequestOptions,
+) (synthient.FeedSnapshotsPage, error) {
+	err := fake.begin("FeedSnapshots", requestOptions)
+	if err != nil {
+		return synthient.FeedSnapshotsPage{}, err
+	}
+	limit, offset := 100, 0
+	if options != nil {
+		if options.Limit > 0 {
+			limit = min(options.Limit, 500)
+		}
+		if options.Cursor != "" {
+			offset, err = strconv.Atoi(options.Cursor)
+			if err != nil || offset < 0 {
+				return synthient.FeedSnapshotsPage{}, fmt.Errorf(
+					"invalid cursor %q: %w", options.Cursor, synthient.ErrBadRequest)
+			}
+		}
+	}
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	return fake.snapshots.page(stream, offset, limit), nil
+}
+
+// FeedSnapshotMeta implements synthient.SnapshotDownloader.
+func (fake *Fake) FeedSnapshotMeta(
+	stream string,
+	date string,
+	requestOptions *synthient.RequestOptions,
+) (synthient.FeedSnapshotMeta, error) {
+	err := fake.begin("FeedSnapshotMeta", requestOptions)
+	if err != nil {
+		return synthient.FeedSnapshotMeta{}, err
+	}
+	fake.mu.Lock()
+	snap := fake.snapshots.find(stream, date)
+	fake.mu.Unlock()
+	if snap == nil {
+		return synthient.FeedSnapshotMeta{}, fmt.Errorf(
+			"%s snapshot %s: %w", stream, date, synthient.ErrNotFound)
+	}
+	return snap.meta(), nil
+}
+
+// DownloadFeedSnapshot implements synthient.SnapshotDownloader. As with the Client, a
+// non-empty filename receives the snapshot and the returned reader is nil.
+func (fake *Fake) DownloadFeedSnapshot(
+	stream string,
+	date string,
+	hour *int,
+	filename string,
+	requestOptions *synthient.RequestOptions,
+) (io.ReadCloser, error) {
+	err := fake.begin("DownloadFeedSnapshot", requestOptions)
+	if err != nil {
+		return nil, err
+	}
+	key := date
+	if hour != nil {
+		key = fmt.Sprintf("%s/%02d", date, *hour)
+	}
+	fake.mu.Lock()
+	snap := fake.snapshots.find(stream, key)
+	fake.mu.Unlock()
+	if snap == nil {
+		return nil, fmt.Errorf("%s snapshot %s: %w", stream, key, synthient.ErrNotFound)
+	}
+
+	if filename == "" {
+		return io.NopCloser(bytes.NewReader(snap.Data)), nil
+	}
+	err = os.WriteFile(filename, snap.Data, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("writing %s snapshot to %s: %w", stream, filename, err)
+	}
+	return nil, nil
+}
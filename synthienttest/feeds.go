@@ -0,0 +1,368 @@
+package synthienttest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/synthient/go-synthient/v2"
+)
+
+// Snapshot is a Parquet snapshot served by the feed export endpoints.
+type Snapshot struct {
+	// Date is the snapshot day, formatted YYYY-MM-DD.
+	Date string
+	// Hour selects an hourly snapshot (0–23). Nil means a daily rollup.
+	Hour *int
+	// Data is the file content served by downloads. Its size and SHA-256 checksum
+	// are reported by the listing and meta endpoints.
+	Data []byte
+	// Rows is the reported row count.
+	Rows int64
+	// Fields is the reported Parquet schema, as name/type pairs.
+	Fields [][2]string
+}
+
+type storedSnapshot struct {
+	Snapshot
+	stream    string
+	id        string
+	checksum  string
+	createdAt time.Time
+}
+
+func (snap *storedSnapshot) key() string {
+	if snap.Hour == nil {
+		return snap.Date
+	}
+	return fmt.Sprintf("%s/%02d", snap.Date, *snap.Hour)
+}
+
+func (snap *storedSnapshot) kind() string {
+	if snap.Hour == nil {
+		return "daily"
+	}
+	return "hourly"
+}
+
+func (snap *storedSnapshot) time() time.Time {
+	t, _ := time.Parse(time.DateOnly, snap.Date)
+	if snap.Hour != nil {
+		t = t.Add(time.Duration(*snap.Hour) * time.Hour)
+	}
+	return t
+}
+
+func (snap *storedSnapshot) entry() synthient.FeedSnapshot {
+	return synthient.FeedSnapshot{
+		Kind:      snap.kind(),
+		Date:      snap.Date,
+		Hour:      snap.Hour,
+		SizeBytes: int64(len(snap.Data)),
+		RowCount:  snap.Rows,
+		Checksum:  snap.checksum,
+		ID:        snap.id,
+		CreatedAt: snap.createdAt.Unix(),
+		DownloadPath: strings.Join(
+			append(append([]string{"", "feeds"}, streamPath(snap.stream)...), "export", snap.key()),
+			"/",
+		),
+	}
+}
+
+func (snap *storedSnapshot) meta() synthient.FeedSnapshotMeta {
+	meta := synthient.FeedSnapshotMeta{
+		Stream:    snap.stream,
+		Kind:      snap.kind(),
+		Hour:      snap.Hour,
+		ID:        snap.id,
+		Format:    "parquet",
+		Date:      snap.time().Unix(),
+		CreatedAt: snap.createdAt.Unix(),
+		Size:      int64(len(snap.Data)),
+		Rows:      snap.Rows,
+		Checksum:  snap.checksum,
+	}
+	for _, field := range snap.Fields {
+		meta.Schema.Fields = append(meta.Schema.Fields, struct {
+			Name string `json:"name"`
+			Type string `json:"type"`
+		}{field[0], field[1]})
+	}
+	return meta
+}
+
+// AddSnapshot registers a snapshot for stream (e.g. "proxies" or "honeypot_http") and
+// returns the listing entry the server reports for it, including its checksum.
+func (server *Server) AddSnapshot(stream string, snapshot Snapshot) synthient.FeedSnapshot {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	return server.snapshots.add(stream, snapshot).entry()
+}
+
+// snapshotStore holds snapshots by stream, newest first. It is not safe for
+// concurrent use; callers guard it with their own mutex.
+type snapshotStore map[string][]*storedSnapshot
+
+func (store snapshotStore) add(stream string, snapshot Snapshot) *storedSnapshot {
+	sum := sha256.Sum256(snapshot.Data)
+	snap := &storedSnapshot{
+		Snapshot:  snapshot,
+		stream:    stream,
+		id:        fmt.Sprintf("snap_%s_%d", stream, len(store[stream])+1),
+		checksum:  hex.EncodeToString(sum[:]),
+		createdAt: time.Now(),
+	}
+	list := append(store[stream], snap)
+	// newest first, as the API orders its listings
+	slices.SortStableFunc(list, func(a, b *storedSnapshot) int {
+		return b.time().Compare(a.time())
+	})
+	store[stream] = list
+	return snap
+}
+
+func (store snapshotStore) page(stream string, offset, limit int) synthient.FeedSnapshotsPage {
+	list := store[stream]
+	page := synthient.FeedSnapshotsPage{Stream: stream, Feeds: []synthient.FeedSnapshot{}}
+	for _, snap := range list[min(offset, len(list)):min(offset+limit, len(list))] {
+		page.Feeds = append(page.Feeds, snap.entry())
+	}
+	if offset+limit < len(list) {
+		page.NextCursor = strconv.Itoa(offset + limit)
+	}
+	return page
+}
+
+// find resolves "latest", YYYY-MM-DD or YYYY-MM-DD/HH snapshot identifiers.
+func (store snapshotStore) find(stream string, date string) *storedSnapshot {
+	list := store[stream]
+	if date == "latest" {
+		for _, snap := range list {
+			if snap.Hour != nil {
+				return snap
+			}
+		}
+		if len(list) > 0 {
+			return list[0]
+		}
+		return nil
+	}
+
+	var hour *int
+	day, rawHour, hourly := strings.Cut(date, "/")
+	if hourly {
+		h, err := strconv.Atoi(rawHour)
+		if err != nil {
+			return nil
+		}
+		hour = &h
+	}
+	for _, snap := range list {
+		if snap.Date != day {
+			continue
+		}
+		if (hour == nil) == (snap.Hour == nil) && (hour == nil || *hour == *snap.Hour) {
+			return snap
+		}
+	}
+	return nil
+}
+
+func (store snapshotStore) byID(id string) *storedSnapshot {
+	for _, list := range store {
+		for _, snap := range list {
+			if snap.id == id {
+				return snap
+			}
+		}
+	}
+	return nil
+}
+
+// Push appends events to a real-time stream (e.g. "proxies", "torrents" or
+// "honeypot_https"). Events are JSON-encoded one per line. Every connection to the
+// stream receives all events pushed so far, then waits for more until the client
+// disconnects or CloseStream is called.
+func (server *Server) Push(stream string, events ...any) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	st := server.streamLocked(stream)
+	for _, event := range events {
+		line, err := json.Marshal(event)
+		if err != nil {
+			panic(fmt.Sprintf("synthienttest: encoding %s event: %s", stream, err))
+		}
+		st.lines = append(st.lines, append(line, '\n'))
+	}
+	st.notifyLocked()
+}
+
+// CloseStream ends connections to stream once they have received every pushed event.
+// Later connections receive the events and are closed immediately.
+func (server *Server) CloseStream(stream string) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	st := server.streamLocked(stream)
+	st.closed = true
+	st.notifyLocked()
+}
+
+type stream struct {
+	lines   [][]byte
+	closed  bool
+	changed chan struct{}
+}
+
+func (server *Server) streamLocked(name string) *stream {
+	st, ok := server.streams[name]
+	if !ok {
+		st = &stream{changed: make(chan struct{})}
+		server.streams[name] = st
+	}
+	return st
+}
+
+func (st *stream) notifyLocked() {
+	close(st.changed)
+	st.changed = make(chan struct{})
+}
+
+func (server *Server) handleFeeds(w http.ResponseWriter, r *http.Request) {
+	segments := strings.Split(strings.Trim(r.PathValue("path"), "/"), "/")
+	name, rest := streamName(segments)
+	switch {
+	case len(rest) == 1 && rest[0] == "stream":
+		server.serveStream(w, r, name)
+	case len(rest) >= 1 && rest[0] == "export":
+		server.serveExport(w, r, name, rest[1:])
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (server *Server) serveStream(w http.ResponseWriter, r *http.Request, name string) {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	sent := 0
+	for {
+		server.mu.Lock()
+		st := server.streamLocked(name)
+		pending := st.lines[sent:]
+		closed, changed := st.closed, st.changed
+		server.mu.Unlock()
+
+		for _, line := range pending {
+			_, err := w.Write(line)
+			if err != nil {
+				return
+			}
+		}
+		sent += len(pending)
+		if flusher != nil {
+			flusher.Flush()
+		}
+		if closed {
+			return
+		}
+		select {
+		case <-r.Context().Done():
+			return
+		case <-changed:
+		}
+	}
+}
+
+func (server *Server) serveExport(w http.ResponseWriter, r *http.Request, name string, rest []string) {
+	if len(rest) == 0 {
+		server.serveListing(w, r, name)
+		return
+	}
+	isMeta := rest[len(rest)-1] == "meta"
+	if isMeta {
+		rest = rest[:len(rest)-1]
+	}
+	snap := server.findSnapshot(name, rest)
+	if snap == nil {
+		writeError(w, http.StatusNotFound, "snapshot not found")
+		return
+	}
+	if isMeta {
+		writeJSON(w, http.StatusOK, snap.meta())
+		return
+	}
+	http.Redirect(w, r, "/_download/"+snap.id+"?signature=synthienttest", http.StatusTemporaryRedirect)
+}
+
+func (server *Server) serveListing(w http.ResponseWriter, r *http.Request, name string) {
+	limit := 100
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 {
+			writeError(w, http.StatusBadRequest, "invalid limit")
+			return
+		}
+		limit = min(parsed, 500)
+	}
+	offset := 0
+	if raw := r.URL.Query().Get("cursor"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 {
+			writeError(w, http.StatusBadRequest, "invalid cursor")
+			return
+		}
+		offset = parsed
+	}
+
+	server.mu.Lock()
+	page := server.snapshots.page(name, offset, limit)
+	server.mu.Unlock()
+	writeJSON(w, http.StatusOK, page)
+}
+
+// findSnapshot resolves "latest", YYYY-MM-DD or YYYY-MM-DD/HH path segments.
+func (server *Server) findSnapshot(name string, rest []string) *storedSnapshot {
+	if len(rest) == 0 || len(rest) > 2 {
+		return nil
+	}
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	return server.snapshots.find(name, strings.Join(rest, "/"))
+}
+
+func (server *Server) handleDownload(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	server.mu.Lock()
+	found := server.snapshots.byID(id)
+	server.mu.Unlock()
+	if found == nil {
+		writeError(w, http.StatusNotFound, "snapshot not found")
+		return
+	}
+	w.Header().Set("Content-Type", "application/vnd.apache.parquet")
+	w.Header().Set("Content-Length", strconv.Itoa(len(found.Data)))
+	_, _ = w.Write(found.Data)
+}
+
+// streamPath maps a public stream name to its API path segments.
+func streamPath(name string) []string {
+	if protocol, ok := strings.CutPrefix(name, "honeypot_"); ok {
+		return []string{"helio", protocol}
+	}
+	return []string{name}
+}
+
+// streamName is the inverse of streamPath, returning the remaining segments.
+func streamName(segments []string) (string, []string) {
+	if len(segments) >= 2 && segments[0] == "helio" {
+		return "honeypot_" + segments[1], segments[2:]
+	}
+	return segments[0], segments[1:]
+}
@@ -0,0 +1,302 @@
+// Package synthienttest provides an in-memory fake of the Synthient REST API for tests.
+//
+// A Server serves the lookup, account, feed snapshot and real-time stream endpoints
+// from data seeded by the test, and can inject failures. Point a synthient.Client at
+// it with Server.Client, or by setting Client.BaseAPI to Server.BaseURL():
+//
+//	server := synthienttest.NewServer()
+//	defer server.Close()
+//
+//	var record synthient.IP
+//	record.IP = "213.149.183.127"
+//	record.Intelligence.RiskScore = 90
+//	server.AddIP(record)
+//
+//	client := server.Client()
+//	ip, err := client.GetIP("213.149.183.127", nil)
+package synthienttest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/synthient/go-synthient/v2"
+)
+
+// Token is the API key accepted by a Server unless SetToken is called.
+const Token = "synthienttest-key"
+
+// Server is a fake Synthient API server. All methods are safe for concurrent use,
+// including while the server is handling requests.
+type Server struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	token     string
+	credits   int
+	requestID int
+	account   synthient.Account
+	ips       map[string]synthient.IP
+	domains   map[string]synthient.Domain
+	streams   map[string]*stream
+	snapshots snapshotStore
+	failures  []*Failure
+	requests  []Request
+}
+
+// Request records a request received by a Server.
+type Request struct {
+	Method string
+	Path   string
+	Query  url.Values
+	APIKey string
+}
+
+// NewServer starts a Server. Callers must Close it when done.
+func NewServer() *Server {
+	server := &Server{
+		token:     Token,
+		credits:   -1,
+		ips:       map[string]synthient.IP{},
+		domains:   map[string]synthient.Domain{},
+		streams:   map[string]*stream{},
+		snapshots: snapshotStore{},
+	}
+	server.Server = httptest.NewServer(server.handler())
+	return server
+}
+
+// BaseURL returns the server's URL for use as synthient.Client.BaseAPI.
+func (server *Server) BaseURL() url.URL {
+	base, err := url.Parse(server.URL)
+	if err != nil {
+		panic(fmt.Sprintf("synthienttest: parsing server url: %s", err))
+	}
+	return *base
+}
+
+// Client returns a synthient.Client pointed at the server and authenticated with its
+// token. options are applied after the test configuration.
+func (server *Server) Client(options ...synthient.Option) synthient.Client {
+	server.mu.Lock()
+	token := server.token
+	server.mu.Unlock()
+	base := []synthient.Option{
+		synthient.WithHTTPClient(server.Server.Client()),
+		synthient.WithBaseAPI(server.BaseURL()),
+		synthient.WithBaseFeeds(server.BaseURL()),
+	}
+	return synthient.NewClient(token, append(base, options...)...)
+}
+
+// SetToken changes the API key the server accepts. An empty token accepts any
+// non-empty key.
+func (server *Server) SetToken(token string) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.token = token
+}
+
+// SetAccount sets the response of the account endpoint. Its lookup credits are also
+// enforced as with SetCredits when non-negative.
+func (server *Server) SetAccount(account synthient.Account) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.account = account
+	server.credits = account.LookupQuota.Credits
+}
+
+// SetCredits enables credit accounting: every IP or domain looked up costs one
+// credit, and lookups fail with 402 Payment Required once credits run out. A negative
+// value (the default) disables accounting.
+func (server *Server) SetCredits(credits int) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.credits = credits
+	server.account.LookupQuota.Credits = max(credits, 0)
+}
+
+// Credits returns the remaining credits, or -1 when accounting is disabled.
+func (server *Server) Credits() int {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	return server.credits
+}
+
+// AddIP seeds the record returned for ip.IP by the IP lookup endpoints. Addresses that
+// were not seeded resolve to a record with only the IP field set.
+func (server *Server) AddIP(ip synthient.IP) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.ips[ip.IP] = ip
+}
+
+// AddDomain seeds the record returned for domain.Domain by the domain lookup endpoint.
+// Domains that were not seeded resolve to a record with only the Domain field set.
+func (server *Server) AddDomain(domain synthient.Domain) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.domains[strings.ToLower(domain.Domain)] = domain
+}
+
+// Requests returns the requests received so far, oldest first.
+func (server *Server) Requests() []Request {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	return append([]Request(nil), server.requests...)
+}
+
+func (server *Server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /account/me", server.handleAccount)
+	mux.HandleFunc("GET /lookup/ip/{ip}", server.handleIP)
+	mux.HandleFunc("POST /lookup/ips", server.handleIPs)
+	mux.HandleFunc("GET /lookup/domain/{domain}", server.handleDomain)
+	mux.HandleFunc("GET /feeds/{path...}", server.handleFeeds)
+	mux.HandleFunc("GET /_download/{id}", server.handleDownload)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.mu.Lock()
+		server.requestID++
+		w.Header().Set("X-Request-Id", fmt.Sprintf("req_%d", server.requestID))
+		server.requests = append(server.requests, Request{
+			Method: r.Method,
+			Path:   r.URL.Path,
+			Query:  r.URL.Query(),
+			APIKey: r.Header.Get("X-Api-Key"),
+		})
+		token := server.token
+		failure := server.matchFailure(r)
+		server.mu.Unlock()
+
+		if failure != nil {
+			failure.write(w)
+			return
+		}
+		// presigned download URLs carry their own authorization
+		if !strings.HasPrefix(r.URL.Path, "/_download/") {
+			key := r.Header.Get("X-Api-Key")
+			if key == "" || (token != "" && key != token) {
+				writeError(w, http.StatusUnauthorized, "invalid api key")
+				return
+			}
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func (server *Server) handleAccount(w http.ResponseWriter, r *http.Request) {
+	server.mu.Lock()
+	account := server.account
+	server.mu.Unlock()
+	writeJSON(w, http.StatusOK, account)
+}
+
+func (server *Server) handleIP(w http.ResponseWriter, r *http.Request) {
+	ip := r.PathValue("ip")
+	record, status, msg := server.lookupIP(ip)
+	if status != http.StatusOK {
+		writeError(w, status, msg)
+		return
+	}
+	if !server.spend(w, 1) {
+		return
+	}
+	writeJSON(w, http.StatusOK, record)
+}
+
+func (server *Server) handleIPs(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		IPs []string `json:"ips"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	results := make([]synthient.IP, 0, len(body.IPs))
+	for _, ip := range body.IPs {
+		record, status, msg := server.lookupIP(ip)
+		if status != http.StatusOK {
+			writeError(w, status, msg)
+			return
+		}
+		results = append(results, record)
+	}
+	if !server.spend(w, len(results)) {
+		return
+	}
+	writeJSON(w, http.StatusOK, struct {
+		Results []synthient.IP `json:"results"`
+	}{results})
+}
+
+func (server *Server) handleDomain(w http.ResponseWriter, r *http.Request) {
+	name := strings.ToLower(r.PathValue("domain"))
+	if !strings.Contains(name, ".") {
+		writeError(w, http.StatusBadRequest, "invalid domain")
+		return
+	}
+	server.mu.Lock()
+	record, ok := server.domains[name]
+	server.mu.Unlock()
+	if !ok {
+		record = synthient.Domain{Domain: name}
+	}
+	if !server.spend(w, 1) {
+		return
+	}
+	writeJSON(w, http.StatusOK, record)
+}
+
+func (server *Server) lookupIP(ip string) (synthient.IP, int, string) {
+	_, err := netip.ParseAddr(ip)
+	if err != nil {
+		return synthient.IP{}, http.StatusBadRequest, "invalid ip address"
+	}
+	server.mu.Lock()
+	record, ok := server.ips[ip]
+	server.mu.Unlock()
+	if !ok {
+		record = synthient.IP{IP: ip}
+	}
+	return record, http.StatusOK, ""
+}
+
+// spend deducts cost credits, writing a 402 response and returning false when the
+// credits do not cover it.
+func (server *Server) spend(w http.ResponseWriter, cost int) bool {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if server.credits < 0 {
+		return true
+	}
+	if server.credits < cost {
+		w.Header().Set("X-Credits-Remaining", fmt.Sprint(server.credits))
+		writeError(w, http.StatusPaymentRequired, "credits have run out")
+		return false
+	}
+	server.credits -= cost
+	server.account.LookupQuota.Credits = server.credits
+	w.Header().Set("X-Credits-Used", fmt.Sprint(cost))
+	w.Header().Set("X-Credits-Remaining", fmt.Sprint(server.credits))
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, struct {
+		Error string `json:"error"`
+	}{msg})
+}
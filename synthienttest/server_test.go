@@ -0,0 +1,182 @@
+package synthienttest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/synthient/go-synthient/v2"
+)
+
+func TestLookups(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	var record synthient.IP
+	record.IP = "213.149.183.127"
+	record.Intelligence.RiskScore = 90
+	server.AddIP(record)
+	server.AddDomain(synthient.Domain{Domain: "example.com", Status: "active"})
+	server.SetCredits(3)
+	client := server.Client()
+
+	ip, err := client.GetIP("213.149.183.127", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ip.Intelligence.RiskScore != 90 {
+		t.Errorf("RiskScore = %d, want 90", ip.Intelligence.RiskScore)
+	}
+	domain, err := client.GetDomain("example.com", nil)
+	if err != nil || domain.Status != "active" {
+		t.Fatalf("GetDomain = %+v, %v", domain, err)
+	}
+	_, err = client.GetIPs([]string{"8.8.8.8", "1.1.1.1"}, nil)
+	if !errors.Is(err, synthient.ErrPaymentRequired) {
+		t.Fatalf("err = %v, want ErrPaymentRequired", err)
+	}
+	if server.Credits() != 1 {
+		t.Errorf("Credits() = %d, want 1", server.Credits())
+	}
+}
+
+func TestFailures(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	server.Fail(Failure{Path: "/lookup/ip/", Status: 503, Times: 2})
+	client := server.Client(synthient.WithRetry(&synthient.RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond}))
+
+	_, err := client.GetIP("8.8.8.8", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(server.Requests()); got != 3 {
+		t.Errorf("server saw %d requests, want 3", got)
+	}
+
+	client.Token = "wrong"
+	_, err = client.GetAccount(nil)
+	if !errors.Is(err, synthient.ErrUnauthorized) {
+		t.Errorf("err = %v, want ErrUnauthorized", err)
+	}
+}
+
+func TestStream(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	server.Push("proxies", synthient.ProxyEvent{IP: "198.51.100.1"}, synthient.ProxyEvent{IP: "198.51.100.2"})
+	client := server.Client()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var got []string
+	for event, err := range client.StreamProxy(&synthient.RequestOptions{Context: ctx}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, event.IP)
+		if len(got) == 2 {
+			server.Push("proxies", synthient.ProxyEvent{IP: "198.51.100.3"})
+			server.CloseStream("proxies")
+		}
+	}
+	if len(got) != 3 || got[2] != "198.51.100.3" {
+		t.Errorf("events = %v", got)
+	}
+}
+
+func TestSnapshots(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	hour := 21
+	data := []byte("PAR1 fake parquet PAR1")
+	server.AddSnapshot("honeypot_https", Snapshot{Date: "2026-05-06", Data: []byte("older")})
+	entry := server.AddSnapshot("honeypot_https", Snapshot{Date: "2026-05-07", Hour: &hour, Data: data, Rows: 12})
+	client := server.Client()
+
+	page, err := client.FeedSnapshots("honeypot_https", &synthient.FeedSnapshotsOptions{Limit: 1}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Feeds) != 1 || page.Feeds[0].ID != entry.ID || page.NextCursor == "" {
+		t.Fatalf("page = %+v", page)
+	}
+
+	meta, err := client.FeedSnapshotMeta("honeypot_https", "2026-05-07/21", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(data)
+	if meta.Checksum != hex.EncodeToString(sum[:]) || meta.Rows != 12 {
+		t.Errorf("meta = %+v", meta)
+	}
+
+	r, err := client.DownloadHeliosTLS("latest", nil, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = r.Close() }()
+	body, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != string(data) {
+		t.Errorf("downloaded %q, want %q", body, data)
+	}
+}
+
+func TestFake(t *testing.T) {
+	fake := NewFake()
+	var record synthient.IP
+	record.IP = "213.149.183.127"
+	record.Intelligence.RiskScore = 90
+	fake.AddIP(record)
+	fake.Push("proxies", synthient.ProxyEvent{IP: "198.51.100.1"}, synthient.ProxyEvent{IP: "198.51.100.2"})
+	hour := 3
+	fake.AddSnapshot("proxies", Snapshot{Date: "2026-05-07", Hour: &hour, Data: []byte("PAR1")})
+
+	var api synthient.API = fake
+	ips, err := api.GetIPs([]string{"213.149.183.127", "8.8.8.8"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ips[0].Intelligence.RiskScore != 90 || ips[1].IP != "8.8.8.8" {
+		t.Errorf("GetIPs = %+v", ips)
+	}
+	_, err = api.GetIP("not-an-ip", nil)
+	if !errors.Is(err, synthient.ErrBadRequest) {
+		t.Errorf("err = %v, want ErrBadRequest", err)
+	}
+
+	var seen []string
+	for event, err := range api.StreamProxy(nil) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen = append(seen, event.IP)
+	}
+	if len(seen) != 2 || seen[1] != "198.51.100.2" {
+		t.Errorf("streamed %v", seen)
+	}
+
+	r, err := api.DownloadFeedSnapshot("proxies", "latest", nil, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(r)
+	if string(data) != "PAR1" {
+		t.Errorf("downloaded %q", data)
+	}
+
+	fake.SetError("GetDomain", synthient.ErrServiceUnavailable)
+	_, err = api.GetDomain("example.com", nil)
+	if !errors.Is(err, synthient.ErrServiceUnavailable) {
+		t.Errorf("err = %v, want ErrServiceUnavailable", err)
+	}
+	if calls := fake.Calls(); len(calls) != 5 || calls[4] != "GetDomain" {
+		t.Errorf("Calls() = %v", calls)
+	}
+}
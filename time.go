@@ -0,0 +1,76 @@
+package synthient
+
+import "time"
+
+// UnixTime converts a Unix timestamp from the API to a UTC time.Time. The API's
+// timestamps are seconds, but some sources report milliseconds, microseconds or
+// nanoseconds; the unit is detected from the magnitude, which is unambiguous for any
+// date between 1973 and 5138. Zero converts to the zero time.Time.
+//
+// Every timestamp field in the SDK has an accessor built on UnixTime, such as
+// ProxyEvent.Time and IPProvider.LastSeenTime.
+func UnixTime(v int64) time.Time {
+	abs := v
+	if abs < 0 {
+		abs = -abs
+	}
+	switch {
+	case v == 0:
+		return time.Time{}
+	case abs >= 1e17:
+		return time.Unix(0, v).UTC()
+	case abs >= 1e14:
+		return time.UnixMicro(v).UTC()
+	case abs >= 1e11:
+		return time.UnixMilli(v).UTC()
+	default:
+		return time.Unix(v, 0).UTC()
+	}
+}
+
+// LastSeenTime returns LastSeen as a time.Time.
+func (provider IPProvider) LastSeenTime() time.Time { return UnixTime(provider.LastSeen) }
+
+// Time returns Timestamp as a time.Time.
+func (event ProxyEvent) Time() time.Time { return UnixTime(event.Timestamp) }
+
+// Time returns Timestamp as a time.Time.
+func (event AnonymizerEvent) Time() time.Time { return UnixTime(event.Timestamp) }
+
+// Time returns Timestamp as a time.Time.
+func (event TorrentEvent) Time() time.Time { return UnixTime(event.Timestamp) }
+
+// Time returns Timestamp as a time.Time.
+func (event HeliosHTTPEvent) Time() time.Time { return UnixTime(event.Timestamp) }
+
+// Time returns Timestamp as a time.Time.
+func (event HeliosTLSEvent) Time() time.Time { return UnixTime(event.Timestamp) }
+
+// Time returns the start of the period the snapshot covers: midnight UTC of Date for
+// a daily rollup, or Hour o'clock UTC for an hourly snapshot. It is the zero time when
+// Date is not formatted YYYY-MM-DD.
+func (snap FeedSnapshot) Time() time.Time {
+	t, err := time.Parse(time.DateOnly, snap.Date)
+	if err != nil {
+		return time.Time{}
+	}
+	if snap.Hour != nil {
+		t = t.Add(time.Duration(*snap.Hour) * time.Hour)
+	}
+	return t
+}
+
+// CreatedTime returns CreatedAt as a time.Time.
+func (snap FeedSnapshot) CreatedTime() time.Time { return UnixTime(snap.CreatedAt) }
+
+// Time returns Date, the start of the period the snapshot covers, as a time.Time.
+func (meta FeedSnapshotMeta) Time() time.Time { return UnixTime(meta.Date) }
+
+// CreatedTime returns CreatedAt as a time.Time.
+func (meta FeedSnapshotMeta) CreatedTime() time.Time { return UnixTime(meta.CreatedAt) }
+
+// Time returns Date, the start of the hour the point covers, as a time.Time.
+func (point DomainTimePoint) Time() time.Time { return UnixTime(int64(point.Date)) }
+
+// Time returns Timestamp as a time.Time.
+func (event DomainEvent) Time() time.Time { return UnixTime(int64(event.Timestamp)) }
@@ -0,0 +1,39 @@
+package synthient
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUnixTimeDetectsUnit(t *testing.T) {
+	want := time.Date(2026, 5, 7, 21, 30, 15, 0, time.UTC)
+	for _, v := range []int64{
+		want.Unix(),
+		want.UnixMilli(),
+		want.UnixMicro(),
+		want.UnixNano(),
+	} {
+		got := UnixTime(v)
+		if !got.Equal(want) || got.Location() != time.UTC {
+			t.Errorf("UnixTime(%d) = %s, want %s", v, got, want)
+		}
+	}
+	if !UnixTime(0).IsZero() {
+		t.Error("UnixTime(0) is not the zero time")
+	}
+}
+
+func TestFeedSnapshotTime(t *testing.T) {
+	hour := 21
+	snap := FeedSnapshot{Date: "2026-05-07", Hour: &hour}
+	if got := snap.Time(); !got.Equal(time.Date(2026, 5, 7, 21, 0, 0, 0, time.UTC)) {
+		t.Errorf("hourly Time() = %s", got)
+	}
+	snap.Hour = nil
+	if got := snap.Time(); !got.Equal(time.Date(2026, 5, 7, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("daily Time() = %s", got)
+	}
+	if got := (FeedSnapshot{Date: "latest"}).Time(); !got.IsZero() {
+		t.Errorf("malformed Time() = %s, want zero", got)
+	}
+}
@@ -0,0 +1,177 @@
+package synthient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TokenProvider supplies the API key for each request. It is consulted on every HTTP
+// attempt and by GRPCSchema, so a provider can rotate keys without rebuilding the
+// Client. Implementations must be safe for concurrent use.
+type TokenProvider interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// TokenRejecter is implemented by providers that can react to a key the server
+// rejected with ErrUnauthorized. Reject is called with the rejected token and reports
+// whether a different token is now available, in which case the request is retried
+// once with it.
+type TokenRejecter interface {
+	Reject(ctx context.Context, token string) bool
+}
+
+// WithTokenProvider sets the provider consulted for the API key on every request,
+// taking precedence over the token passed to NewClient.
+func WithTokenProvider(provider TokenProvider) Option {
+	return func(client *Client) {
+		client.Tokens = provider
+	}
+}
+
+// token returns the API key to use for the next request.
+func (client *Client) token(ctx context.Context) (string, error) {
+	token := client.Token
+	if client.Tokens != nil {
+		var err error
+		token, err = client.Tokens.Token(ctx)
+		if err != nil {
+			return "", fmt.Errorf("getting api token: %w", err)
+		}
+	}
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return "", ErrNoToken
+	}
+	return token, nil
+}
+
+// StaticToken is a TokenProvider that always returns the same key.
+type StaticToken string
+
+// Token returns the key.
+func (token StaticToken) Token(context.Context) (string, error) {
+	return string(token), nil
+}
+
+// EnvToken returns a TokenProvider that reads the environment variable name on every
+// call. A missing or empty variable results in ErrNoToken.
+func EnvToken(name string) TokenProvider {
+	return envToken(name)
+}
+
+type envToken string
+
+func (name envToken) Token(context.Context) (string, error) {
+	token := strings.TrimSpace(os.Getenv(string(name)))
+	if token == "" {
+		return "", fmt.Errorf("reading %s: %w", string(name), ErrNoToken)
+	}
+	return token, nil
+}
+
+// FileToken is a TokenProvider that reads the key from a file, such as a mounted
+// secret, and re-reads it whenever the file's modification time or size changes.
+// Surrounding whitespace is trimmed.
+type FileToken struct {
+	path string
+
+	mu      sync.Mutex
+	token   string
+	modTime time.Time
+	size    int64
+}
+
+// NewFileToken returns a FileToken reading path. The file is read lazily on first use.
+func NewFileToken(path string) *FileToken {
+	return &FileToken{path: path}
+}
+
+// Token returns the current key, reloading the file if it changed since the last read.
+func (provider *FileToken) Token(context.Context) (string, error) {
+	info, err := os.Stat(provider.path)
+	if err != nil {
+		return "", fmt.Errorf("reading token file %s: %w", provider.path, err)
+	}
+
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+	if provider.token != "" && info.ModTime().Equal(provider.modTime) && info.Size() == provider.size {
+		return provider.token, nil
+	}
+	data, err := os.ReadFile(provider.path)
+	if err != nil {
+		return "", fmt.Errorf("reading token file %s: %w", provider.path, err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("reading token file %s: %w", provider.path, ErrNoToken)
+	}
+	provider.token = token
+	provider.modTime = info.ModTime()
+	provider.size = info.Size()
+	return token, nil
+}
+
+// FailoverToken is a TokenProvider that uses Primary until the server rejects its key
+// with ErrUnauthorized, then switches to Secondary. After RetryPrimaryAfter has
+// elapsed (if non-zero) the primary key is tried again.
+type FailoverToken struct {
+	Primary           TokenProvider
+	Secondary         TokenProvider
+	RetryPrimaryAfter time.Duration
+
+	mu       sync.Mutex
+	failedAt time.Time
+}
+
+// Token returns the key of the active provider.
+func (provider *FailoverToken) Token(ctx context.Context) (string, error) {
+	if provider.usingSecondary() {
+		return provider.Secondary.Token(ctx)
+	}
+	return provider.Primary.Token(ctx)
+}
+
+// Reject switches to Secondary when the rejected token belongs to Primary.
+func (provider *FailoverToken) Reject(ctx context.Context, token string) bool {
+	if provider.Secondary == nil || provider.usingSecondary() {
+		return false
+	}
+	primary, err := provider.Primary.Token(ctx)
+	if err != nil || primary != token {
+		// the primary key has rotated since the request was made; try it again
+		return err == nil
+	}
+	provider.mu.Lock()
+	provider.failedAt = time.Now()
+	provider.mu.Unlock()
+	return true
+}
+
+func (provider *FailoverToken) usingSecondary() bool {
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+	if provider.failedAt.IsZero() {
+		return false
+	}
+	if provider.RetryPrimaryAfter > 0 && time.Since(provider.failedAt) >= provider.RetryPrimaryAfter {
+		provider.failedAt = time.Time{}
+		return false
+	}
+	return true
+}
+
+// rejectToken reports err's token to the client's provider, returning whether the
+// request should be retried with a new token.
+func (client *Client) rejectToken(ctx context.Context, token string, err error) bool {
+	rejecter, ok := client.Tokens.(TokenRejecter)
+	if !ok || !errors.Is(err, ErrUnauthorized) {
+		return false
+	}
+	return rejecter.Reject(ctx, token)
+}
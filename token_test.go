@@ -0,0 +1,72 @@
+package synthient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileTokenRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api-key")
+	err := os.WriteFile(path, []byte("first\n"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := NewFileToken(path)
+
+	token, err := provider.Token(context.Background())
+	if err != nil || token != "first" {
+		t.Fatalf("Token() = %q, %v; want first", token, err)
+	}
+
+	err = os.WriteFile(path, []byte("second-key"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Chtimes(path, time.Now(), time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err = provider.Token(context.Background())
+	if err != nil || token != "second-key" {
+		t.Fatalf("Token() after rotation = %q, %v; want second-key", token, err)
+	}
+}
+
+func TestFailoverTokenOnUnauthorized(t *testing.T) {
+	var keys []string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("X-Api-Key")
+		keys = append(keys, key)
+		if key != "secondary" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"ip":"8.8.8.8"}`))
+	}))
+	client.Token = ""
+	client.Tokens = &FailoverToken{Primary: StaticToken("primary"), Secondary: StaticToken("secondary")}
+
+	for range 2 {
+		_, err := client.GetIP("8.8.8.8", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []string{"primary", "secondary", "secondary"}
+	if len(keys) != len(want) || keys[0] != want[0] || keys[1] != want[1] || keys[2] != want[2] {
+		t.Errorf("keys sent = %v, want %v", keys, want)
+	}
+}
+
+func TestEnvTokenMissing(t *testing.T) {
+	t.Setenv("SYNTHIENT_TEST_KEY", "")
+	_, err := EnvToken("SYNTHIENT_TEST_KEY").Token(context.Background())
+	if !errors.Is(err, ErrNoToken) {
+		t.Errorf("err = %v, want ErrNoToken", err)
+	}
+}